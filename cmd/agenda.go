@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/garaemon/org-agenda-cli/pkg/agenda"
-	"github.com/garaemon/org-agenda-cli/pkg/config"
+	"github.com/garaemon/org-agenda-cli/pkg/dateinput"
 	"github.com/garaemon/org-agenda-cli/pkg/item"
 	"github.com/garaemon/org-agenda-cli/pkg/parser"
 	"github.com/garaemon/org-agenda-cli/pkg/tui"
@@ -20,6 +20,7 @@ var (
 	agendaTag           string
 	agendaTui           bool
 	agendaNoInteractive bool
+	agendaSort          string
 )
 
 // agendaCmd represents the agenda command
@@ -28,17 +29,14 @@ var agendaCmd = &cobra.Command{
 	Short: "Displays the agenda view",
 	Long:  `Displays the agenda view. Aggregates tasks with schedules and deadlines within a specified period.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var start time.Time
-		var err error
-
+		start := time.Now()
 		if agendaDate != "" {
-			start, err = time.Parse("2006-01-02", agendaDate)
+			d, err := dateinput.Parse(agendaDate, start)
 			if err != nil {
-				fmt.Printf("Invalid date format: %v. Use YYYY-MM-DD.\n", agendaDate)
+				fmt.Printf("Invalid date format: %v. Use YYYY-MM-DD or relative input such as +1w or fri.\n", agendaDate)
 				return
 			}
-		} else {
-			start = time.Now()
+			start = d.Time
 		}
 
 		// Adjust start date based on range (Sunday for week, 1st for month)
@@ -64,25 +62,23 @@ var agendaCmd = &cobra.Command{
 
 		useTui := agendaTui && !agendaNoInteractive
 
-		orgFiles := config.ResolveOrgFiles(paths)
+		sortKeys, err := agenda.ParseSortKeys(agendaSort)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
 		if !useTui {
 			fmt.Printf("Agenda for %s to %s:\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
 		}
 
-		var allItems []*item.Item
-		for _, file := range orgFiles {
-			content, err := os.ReadFile(file)
-			if err != nil {
-				continue
-			}
-
-			items := parser.ParseString(string(content), file)
-			if useTui {
-				allItems = append(allItems, items...)
-			} else {
-				filtered := agenda.FilterItemsByRange(items, start, end)
-				allItems = append(allItems, filtered...)
-			}
+		allItems, _ := newService(paths).LoadItems()
+		if !useTui {
+			allItems = agenda.FilterItemsByRange(allItems, start, end)
+		}
+		if err := agenda.SortItems(allItems, sortKeys); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
 		if useTui {
@@ -116,15 +112,55 @@ var agendaCmd = &cobra.Command{
 			}
 			fmt.Printf("%s: [%s] %s (%s:%d)\n", dateStr, item.Status, item.Title, item.FilePath, item.LineNumber)
 		}
+
+		if agendaRange == "week" {
+			printEffortSummary(allItems, start, end)
+		}
 	},
 }
 
+// printEffortSummary prints the effort planned on each day of the range and
+// flags the days that exceed the configured daily capacity.
+func printEffortSummary(items []*item.Item, start, end time.Time) {
+	days := agenda.SumEffortByDay(items, start, end)
+	var total time.Duration
+	for _, d := range days {
+		total += d.Effort
+	}
+	if total == 0 {
+		return
+	}
+
+	var capacity time.Duration
+	if c := viper.GetString("daily_capacity"); c != "" {
+		var err error
+		capacity, err = parser.ParseDuration(c)
+		if err != nil {
+			fmt.Printf("Invalid daily_capacity %q: %v\n", c, err)
+			capacity = 0
+		}
+	}
+
+	fmt.Println("Effort:")
+	for _, d := range days {
+		line := fmt.Sprintf("  %s: %s scheduled", d.Date.Format("Mon"), parser.FormatDuration(d.Effort))
+		if capacity > 0 {
+			line += fmt.Sprintf(" / %s capacity", parser.FormatDuration(capacity))
+			if d.Effort > capacity {
+				line += fmt.Sprintf(" (over by %s)", parser.FormatDuration(d.Effort-capacity))
+			}
+		}
+		fmt.Println(line)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(agendaCmd)
 
 	agendaCmd.Flags().StringVar(&agendaRange, "range", "day", "Specify the display range (day|week|month)")
-	agendaCmd.Flags().StringVar(&agendaDate, "date", "", "Specify the reference date (YYYY-MM-DD, default: today)")
+	agendaCmd.Flags().StringVar(&agendaDate, "date", "", "Specify the reference date (YYYY-MM-DD or relative input such as +1w or fri, default: today)")
 	agendaCmd.Flags().StringVar(&agendaTag, "tag", "", "Filter items by a specific tag")
+	agendaCmd.Flags().StringVar(&agendaSort, "sort", "", "Comma-separated sort keys, prefix with '-' to reverse (default: priority,date)")
 	agendaCmd.Flags().BoolVar(&agendaTui, "tui", true, "Enable interactive TUI mode")
 	agendaCmd.Flags().BoolVar(&agendaNoInteractive, "no-interactive", false, "Disable interactive TUI mode")
 	agendaCmd.Flags().BoolVar(&agendaNoInteractive, "no-pager", false, "Disable interactive TUI mode")
@@ -159,3 +159,51 @@ func TestAgendaRangeMonth(t *testing.T) {
 		t.Errorf("Output should NOT contain 'Far Task', got:\n%s", output)
 	}
 }
+
+func TestAgendaWeekEffortSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `* TODO Long Task
+SCHEDULED: <2026-01-06 Tue>
+:PROPERTIES:
+:EFFORT: 8:00
+:END:
+* TODO Short Task
+SCHEDULED: <2026-01-06 Tue>
+:PROPERTIES:
+:EFFORT: 1:30
+:END:
+`
+	file := filepath.Join(tmpDir, "test.org")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{tmpDir})
+	viper.Set("daily_capacity", "8:00")
+
+	agendaDate = "2026-01-06"
+	agendaRange = "week"
+	agendaTui = false
+	defer func() { agendaRange = "day" }()
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	agendaCmd.Run(agendaCmd, []string{})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Tue: 9:30 scheduled / 8:00 capacity") {
+		t.Errorf("Output should contain the Tuesday effort summary, got:\n%s", output)
+	}
+	if !strings.Contains(output, "over by 1:30") {
+		t.Errorf("Output should flag the overloaded day, got:\n%s", output)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+	"github.com/garaemon/org-agenda-cli/pkg/service"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	archiveOlderThan string
+	archiveDryRun    bool
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive [file:line|id]",
+	Short: "Move finished subtrees to their archive location",
+	Long: `Move a task with its body and children to its archive location.
+
+The location is taken from the nearest :ARCHIVE: property, the file's #+ARCHIVE
+keyword or the archive_location setting, in the syntax of org-archive-location:
+"file::heading", where %s stands for the name of the task's file, e.g.
+"%s_archive::* From %s". The default is "%s_archive::". The archived entry
+records its origin in ARCHIVE_* properties.
+
+--done-older-than archives every done task closed more than the given duration
+ago (e.g. 30d or 2w) instead of a single task, and --dry-run only lists what
+would move.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if (len(args) == 1) == (archiveOlderThan != "") {
+			fmt.Println("Error: specify either a task or --done-older-than")
+			return
+		}
+
+		svc := newService(viper.GetStringSlice("org_files"))
+		var results []*service.ArchiveResult
+		if archiveOlderThan != "" {
+			age, err := parser.ParseDuration(archiveOlderThan)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			results, err = svc.ArchiveDone(age, archiveDryRun)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		} else {
+			result, err := svc.Archive(args[0], archiveDryRun, editOptions())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			results = append(results, result)
+		}
+
+		verb := "Archived"
+		if archiveDryRun {
+			verb = "Would archive"
+		}
+		for _, r := range results {
+			fmt.Printf("%s %s:%d %s -> %s\n", verb, r.Item.FilePath, r.Item.LineNumber, r.Item.Title, r.Location())
+		}
+		if len(results) == 0 {
+			fmt.Println("Nothing to archive")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+
+	addEditFlags(archiveCmd)
+	archiveCmd.Flags().StringVar(&archiveOlderThan, "done-older-than", "", "Archive all done tasks closed longer ago than this duration, e.g. 30d")
+	archiveCmd.Flags().BoolVar(&archiveDryRun, "dry-run", false, "List what would be archived without changing any file")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestArchive(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "* DONE Finished\nCLOSED: [2020-01-02 Thu 09:00]\n* TODO Open\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	defer func() { archiveOlderThan, archiveDryRun = "", false }()
+
+	run := func(args ...string) string {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		archiveCmd.Run(archiveCmd, args)
+
+		_ = w.Close()
+		os.Stdout = old
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	archiveOlderThan, archiveDryRun = "30d", true
+	out := run()
+	if !strings.Contains(out, "Would archive "+file+":1 Finished -> "+file+"_archive::") {
+		t.Errorf("Unexpected dry run output: %s", out)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != content {
+		t.Errorf("Dry run changed the file: %q", data)
+	}
+
+	archiveOlderThan, archiveDryRun = "", false
+	out = run(file + ":1")
+	if !strings.Contains(out, "Archived") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	data, _ = os.ReadFile(file)
+	if string(data) != "* TODO Open\n" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+	if _, err := os.Stat(file + "_archive"); err != nil {
+		t.Errorf("Archive file not created: %v", err)
+	}
+}
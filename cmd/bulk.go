@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/garaemon/org-agenda-cli/pkg/service"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	bulkMatch      string
+	bulkSetState   string
+	bulkSchedule   string
+	bulkAddTags    []string
+	bulkRemoveTags []string
+	bulkArchive    bool
+	bulkDryRun     bool
+)
+
+var bulkCmd = &cobra.Command{
+	Use:   "bulk --match QUERY",
+	Short: "Change every task matching a query at once",
+	Long: `Apply a change to every task of the configured files that matches a query.
+
+The query uses the syntax of Org's tags/property matches:
+
+  work-talk             tagged work but not talk
+  work|home             tagged work or home
+  OWNER="bob"           property comparisons with strings, {regexps} and numbers
+  PRIORITY="A"&LEVEL=2  TODO, LEVEL and PRIORITY refer to the headline
+  +work/TODO|WAITING    after "/", the TODO keywords to keep ("/!" for open ones)
+
+All files are locked for the whole operation and nothing is written unless
+every change succeeds. The changes form a single entry of the undo journal.
+--dry-run prints them as a unified diff instead. Dependencies between tasks
+are not checked.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if bulkMatch == "" {
+			fmt.Println("Error: --match is required")
+			return
+		}
+		svc := newService(viper.GetStringSlice("org_files"))
+		result, err := svc.Bulk(bulkMatch, service.BulkOptions{
+			State:      bulkSetState,
+			Schedule:   bulkSchedule,
+			AddTags:    bulkAddTags,
+			RemoveTags: bulkRemoveTags,
+			Archive:    bulkArchive,
+			DryRun:     bulkDryRun,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if bulkDryRun {
+			for _, c := range result.Changes {
+				fmt.Print(c.Diff())
+			}
+		}
+		switch {
+		case len(result.Items) == 0:
+			fmt.Println("No matching tasks")
+		case bulkDryRun:
+			fmt.Printf("Would update %d tasks in %d files\n", len(result.Items), len(result.Changes))
+		default:
+			fmt.Printf("Updated %d tasks in %d files\n", len(result.Items), len(result.Changes))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bulkCmd)
+
+	bulkCmd.Flags().StringVarP(&bulkMatch, "match", "m", "", "Tags/property match selecting the tasks")
+	bulkCmd.Flags().StringVar(&bulkSetState, "set-state", "", "Change the TODO keyword of the tasks")
+	bulkCmd.Flags().StringVar(&bulkSchedule, "schedule", "", "Schedule the tasks, e.g. 2026-01-10, +1w or fri")
+	bulkCmd.Flags().StringSliceVar(&bulkAddTags, "add-tag", nil, "Tags to add to the tasks (comma-separated)")
+	bulkCmd.Flags().StringSliceVar(&bulkRemoveTags, "remove-tag", nil, "Tags to remove from the tasks (comma-separated)")
+	bulkCmd.Flags().BoolVar(&bulkArchive, "archive", false, "Move the tasks to their archive location")
+	bulkCmd.Flags().BoolVar(&bulkDryRun, "dry-run", false, "Print the changes as a unified diff without writing them")
+}
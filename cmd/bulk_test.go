@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestBulk(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work.org")
+	home := filepath.Join(dir, "home.org")
+	if err := os.WriteFile(work, []byte("* TODO Report :work:\n* TODO Slides :talk:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(home, []byte("* TODO Taxes :work:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{work, home})
+	viper.Set("tags_column", 0)
+	defer func() {
+		bulkMatch, bulkSetState, bulkSchedule = "", "", ""
+		bulkAddTags, bulkRemoveTags = nil, nil
+		bulkArchive, bulkDryRun = false, false
+	}()
+
+	out := runCmd(bulkCmd)
+	if !strings.Contains(out, "Error: --match is required") {
+		t.Errorf("Unexpected output without --match: %s", out)
+	}
+
+	bulkMatch, bulkAddTags, bulkDryRun = "work", []string{"q1"}, true
+	out = runCmd(bulkCmd)
+	if !strings.Contains(out, "-* TODO Report :work:\n+* TODO Report :work:q1:\n") || !strings.Contains(out, "Would update 2 tasks in 2 files") {
+		t.Errorf("Unexpected dry run output:\n%s", out)
+	}
+	if data, _ := os.ReadFile(work); !strings.HasPrefix(string(data), "* TODO Report :work:\n") {
+		t.Errorf("Dry run changed the file: %q", data)
+	}
+
+	bulkDryRun = false
+	out = runCmd(bulkCmd)
+	if !strings.Contains(out, "Updated 2 tasks in 2 files") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	if data, _ := os.ReadFile(home); string(data) != "* TODO Taxes :work:q1:\n" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+
+	bulkMatch, bulkAddTags = "missing", nil
+	bulkSetState = "DONE"
+	out = runCmd(bulkCmd)
+	if !strings.Contains(out, "No matching tasks") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
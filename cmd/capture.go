@@ -1,75 +1,280 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/garaemon/org-agenda-cli/pkg/agenda"
 	"github.com/garaemon/org-agenda-cli/pkg/capture"
+	"github.com/garaemon/org-agenda-cli/pkg/dateinput"
+	"github.com/garaemon/org-agenda-cli/pkg/service"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var captureFile string
+var (
+	captureFile     string
+	captureEdit     bool
+	captureTemplate string
+	captureDate     string
+	captureVars     []string
+	captureLink     string
+	captureUnder    string
+)
 
 var captureCmd = &cobra.Command{
-	Use:   "capture [content]",
+	Use:   "capture [content | -]",
 	Short: "Capture a note to an Org file",
-	Long:  `Capture a note to an Org file using a configurable format.`,
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Capture a note to an Org file using a configurable format.
+
+Templates listed under capture.templates are selected by their key with -t,
+or picked interactively when -t is omitted. Without templates the capture
+settings describe a single default template.
+
+Standard input is read when "-" is given, e.g. "git log -1 | org-agenda
+capture -t log - Commit", or when it is piped and there are no arguments. It
+is the initial content inserted for %i and, without other arguments, also the
+content, so that its first line becomes the headline of an entry. Further
+lines of %c and %i are indented like the escape. Input that is not text or
+larger than 1 MiB is refused.
+
+--under captures below the headline with the given :ID: or :CUSTOM_ID:, in
+whichever configured file it is: entries become children of the headline and
+other types notes in its body.
+
+--date captures as if on another day, e.g. "yesterday" or "2026-01-05": date
+trees file the entry under that day and the date placeholders refer to it.
+
+Templates may prompt for values with %^{Prompt|default|choice2}, %^g (tags),
+%^t, %^T, %^u, %^U (dates) and %^{PROP}p (a property of the entry); %\1
+repeats the answer to the first %^{...} prompt. Prompts are asked on the
+terminal unless answered with --var Prompt=value. When standard input is not
+a terminal, unanswered prompts take their default or fail.
+
+Other escapes are %c (the content), %i (the initial content), %t and %T
+(active timestamp), %u and %U (inactive timestamp without and with the time),
+%<strftime format>, %a (the --link as Org link) and %l (the link as is), %L
+(the working directory), %f and %F (name and path of the target file) and %k
+and %K (title of and link to the clocked-in task). %(command) inserts the
+output of a shell command when capture.shell_escapes is enabled. "\%"
+inserts a literal "%".`,
 	Run: func(cmd *cobra.Command, args []string) {
+		fromStdin := len(args) > 0 && args[0] == "-"
+		if fromStdin {
+			args = args[1:]
+		}
 		content := strings.Join(args, " ")
 
-		// Determine target file
-		targetFile := captureFile
-		if targetFile == "" {
-			targetFile = viper.GetString("capture.default_file")
+		var initial string
+		// Content given as arguments leaves piped input alone, e.g. for a
+		// loop reading it line by line or a daemon with an open stdin.
+		if fromStdin || (content == "" && stdinIsPiped()) {
+			var err error
+			if initial, err = capture.ReadInput(os.Stdin, capture.MaxInputSize); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if content == "" {
+				content = initial
+			}
 		}
-		if targetFile == "" {
-			targetFile = viper.GetString("default_file")
+		if strings.TrimSpace(content) == "" {
+			fmt.Println("Error: nothing to capture")
+			return
 		}
-		if targetFile == "" {
-			orgFiles := viper.GetStringSlice("org_files")
-			if len(orgFiles) > 0 {
-				targetFile = orgFiles[0]
+
+		at := time.Now()
+		if captureDate != "" {
+			d, err := dateinput.Parse(captureDate, at)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
 			}
+			if !d.HasTime {
+				// Keep the current time of day on the given date.
+				d.Time = time.Date(d.Time.Year(), d.Time.Month(), d.Time.Day(), at.Hour(), at.Minute(), at.Second(), 0, at.Location())
+			}
+			at = d.Time
 		}
 
-		if targetFile == "" {
-			fmt.Println("Error: No target file specified and no default file configured.")
+		answers, err := parseVars(captureVars)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		// Apply date formatting to targetFile (e.g. %Y-capture.org -> 2026-capture.org)
-		targetFile = capture.Format(targetFile, "")
-
-		// Determine format
-		format := viper.GetString("capture.format")
-		if format == "" {
-			format = "* %t\n  %c"
+		svc := newService(viper.GetStringSlice("org_files"))
+		tmpl, err := selectTemplate(svc, captureTemplate)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if captureFile != "" {
+			tmpl.File = captureFile
+		}
+		if captureUnder != "" {
+			tmpl.Target, tmpl.ID, tmpl.File = capture.TargetID, captureUnder, ""
+		}
+		if tmpl.File == "" && tmpl.Target != capture.TargetID {
+			fmt.Println("Error: No target file specified and no default file configured.")
+			return
+		}
+		if tmpl, err = svc.ResolveCaptureTarget(tmpl, at); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
-		// Format entry
-		entry := capture.Format(format, content)
-
-		// Ensure newline at the end
-		if !strings.HasSuffix(entry, "\n") {
-			entry += "\n"
+		var prompter capture.Prompter = answers
+		if !stdinIsPiped() {
+			prompter = &terminalPrompter{
+				answers: answers,
+				in:      bufio.NewReader(os.Stdin),
+				out:     os.Stdout,
+				tags: func() []string {
+					items, _ := svc.LoadItems()
+					return agenda.ExtractUniqueTags(items)
+				},
+			}
+		}
+		in := svc.CaptureInput(content, at)
+		in.Initial = initial
+		in.Link = captureLink
+		in.Prompter = prompter
+		entry, warnings, err := tmpl.Entry(in)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stderr, "Warning:", w)
 		}
 
-		prepend := viper.GetBool("capture.prepend")
-		heading := viper.GetString("capture.heading")
-		olp := viper.GetStringSlice("capture.olp")
+		if captureEdit {
+			if entry, err = reviewEntry(entry); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if strings.TrimSpace(entry) == "" {
+				fmt.Println("Error: the entry is empty")
+				return
+			}
+			if !strings.HasSuffix(entry, "\n") {
+				entry += "\n"
+			}
+		}
 
-		if err := capture.Insert(targetFile, heading, olp, entry, prepend); err != nil {
+		file, err := svc.Capture(tmpl, entry, at)
+		if err != nil {
 			fmt.Printf("Error capturing to file: %v\n", err)
 			return
 		}
 
-		fmt.Printf("Captured to %s\n", targetFile)
+		fmt.Printf("Captured to %s\n", file)
 	},
 }
 
+// selectTemplate returns the template with key, the only template, or the
+// one picked on the terminal.
+func selectTemplate(svc *service.Service, key string) (capture.Template, error) {
+	if key == "" && len(svc.CaptureTemplates) > 1 && !stdinIsPiped() {
+		return pickTemplate(svc.CaptureTemplates, os.Stdin, os.Stdout)
+	}
+	return svc.CaptureTemplate(key)
+}
+
+// pickTemplate lists templates on out and returns the one whose key is read
+// from in.
+func pickTemplate(templates []capture.Template, in io.Reader, out io.Writer) (capture.Template, error) {
+	fmt.Fprintln(out, "Select a capture template:")
+	for _, t := range templates {
+		fmt.Fprintf(out, "  [%s] %s\n", t.Key, t.Description)
+	}
+	fmt.Fprint(out, "Key: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return capture.Template{}, fmt.Errorf("failed to read the template key: %w", err)
+	}
+	key := strings.TrimSpace(line)
+	if key == "" {
+		return capture.Template{}, fmt.Errorf("no capture template selected")
+	}
+	for _, t := range templates {
+		if t.Key == key {
+			return t, nil
+		}
+	}
+	return capture.Template{}, fmt.Errorf("unknown capture template %q", key)
+}
+
+// parseVars reads the --var values, given as NAME=value.
+func parseVars(vars []string) (capture.Answers, error) {
+	answers := capture.Answers{}
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q, expected NAME=value", v)
+		}
+		answers[name] = value
+	}
+	return answers, nil
+}
+
+// terminalPrompter asks the prompts of a template on the terminal, unless
+// they were answered with --var. tags lists the existing tags offered for
+// tag prompts.
+type terminalPrompter struct {
+	answers capture.Answers
+	in      *bufio.Reader
+	out     io.Writer
+	tags    func() []string
+}
+
+func (p *terminalPrompter) Ask(prompt capture.Prompt) (string, error) {
+	if value, ok := p.answers[prompt.Name]; ok {
+		return value, nil
+	}
+
+	choices := prompt.Choices
+	if prompt.Kind == capture.PromptTags && p.tags != nil {
+		choices = p.tags()
+	}
+	if len(choices) > 0 {
+		fmt.Fprintf(p.out, "(%s)\n", strings.Join(choices, ", "))
+	}
+	if len(prompt.Choices) > 0 {
+		fmt.Fprintf(p.out, "%s [%s]: ", prompt.Name, prompt.Choices[0])
+	} else {
+		fmt.Fprintf(p.out, "%s: ", prompt.Name)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		// Nothing more to read: answer as if it was not asked.
+		fmt.Fprintln(p.out)
+		return p.answers.Ask(prompt)
+	}
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read the answer: %w", err)
+	}
+	answer := strings.TrimSpace(line)
+	if answer == "" && len(prompt.Choices) > 0 {
+		return prompt.Choices[0], nil
+	}
+	return answer, nil
+}
+
 func init() {
 	rootCmd.AddCommand(captureCmd)
 	captureCmd.Flags().StringVar(&captureFile, "file", "", "Specify the target file")
+	captureCmd.Flags().BoolVar(&captureEdit, "edit", false, "Review the formatted entry in $EDITOR before capturing it")
+	captureCmd.Flags().StringVarP(&captureTemplate, "template", "t", "", "Key of the capture template to use")
+	captureCmd.Flags().StringArrayVar(&captureVars, "var", nil, "Answer a template prompt without asking, as NAME=value (repeatable)")
+	captureCmd.Flags().StringVar(&captureLink, "link", "", "Link inserted for %a, e.g. file:/path/to/file.go::42")
+	captureCmd.Flags().StringVar(&captureUnder, "under", "", "Capture below the headline with this ID in any configured file")
+	captureCmd.Flags().StringVar(&captureDate, "date", "", "Capture as if on this date (YYYY-MM-DD or relative input such as yesterday or -2d)")
 }
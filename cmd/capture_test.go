@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
+	"github.com/spf13/viper"
+)
+
+func TestCaptureTemplates(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	inbox := filepath.Join(dir, "inbox.org")
+	lists := filepath.Join(dir, "lists.org")
+	if err := os.WriteFile(inbox, []byte("* Tasks\n* Other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lists, []byte("* Shopping\n- Milk\n* Books\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{inbox, lists})
+	viper.Set("capture.templates", []map[string]interface{}{
+		{"key": "t", "description": "Task", "file": inbox, "headline": "Tasks", "template": "* TODO %c", "empty_lines": 1},
+		{"key": "s", "description": "Shopping", "type": "checkitem", "file": lists, "headline": "Shopping"},
+	})
+	defer func() { captureTemplate = "" }()
+
+	captureTemplate = "t"
+	if out := runCmd(captureCmd, "Call", "Bob"); !strings.Contains(out, "Captured to "+inbox) {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	if data, _ := os.ReadFile(inbox); string(data) != "* Tasks\n\n** TODO Call Bob\n\n* Other\n" {
+		t.Errorf("Unexpected inbox: %q", data)
+	}
+
+	captureTemplate = "s"
+	runCmd(captureCmd, "Eggs")
+	if data, _ := os.ReadFile(lists); string(data) != "* Shopping\n- Milk\n- [ ] Eggs\n* Books\n" {
+		t.Errorf("Unexpected lists: %q", data)
+	}
+
+	captureTemplate = "x"
+	if out := runCmd(captureCmd, "Lost"); !strings.Contains(out, `Error: unknown capture template "x"`) {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
+
+func TestPickTemplate(t *testing.T) {
+	viper.Reset()
+	viper.Set("capture.templates", []map[string]interface{}{
+		{"key": "j", "description": "Journal", "file": "journal.org"},
+		{"key": "t", "description": "Task", "file": "inbox.org"},
+	})
+	templates := loadConfig().CaptureTemplates()
+
+	var out bytes.Buffer
+	tmpl, err := pickTemplate(templates, strings.NewReader("t\n"), &out)
+	if err != nil || tmpl.Key != "t" || tmpl.File != "inbox.org" {
+		t.Errorf("pickTemplate() = %+v, %v", tmpl, err)
+	}
+	if !strings.Contains(out.String(), "  [j] Journal\n  [t] Task\n") {
+		t.Errorf("Unexpected prompt: %q", out.String())
+	}
+	if _, err := pickTemplate(templates, strings.NewReader("\n"), &out); err == nil {
+		t.Error("Expected an error without a selection")
+	}
+}
+
+func TestCaptureDatetree(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	journal := filepath.Join(t.TempDir(), "journal.org")
+	if err := os.WriteFile(journal, []byte("* 2026\n** 2026-01 January\n*** 2026-01-06 Tuesday\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{journal})
+	viper.Set("capture.templates", []map[string]interface{}{
+		{"key": "j", "description": "Journal", "target": "file+datetree", "file": journal, "template": "* %c (%d)"},
+	})
+	defer func() { captureTemplate, captureDate = "", "" }()
+
+	captureTemplate, captureDate = "j", "2026-01-05"
+	if out := runCmd(captureCmd, "Standup"); !strings.Contains(out, "Captured to "+journal) {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	want := "* 2026\n** 2026-01 January\n*** 2026-01-05 Monday\n**** Standup (05)\n*** 2026-01-06 Tuesday\n"
+	if data, _ := os.ReadFile(journal); string(data) != want {
+		t.Errorf("Unexpected journal:\ngot  %q\nwant %q", data, want)
+	}
+
+	captureDate = "not a date"
+	if out := runCmd(captureCmd, "Lost"); !strings.HasPrefix(out, "Error: ") {
+		t.Errorf("Expected an error for an invalid date, got: %s", out)
+	}
+}
+
+func TestCapturePrompts(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "inbox.org")
+	if err := os.WriteFile(file, []byte("* Existing :home:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	viper.Set("capture.templates", []map[string]interface{}{
+		{"key": "b", "description": "Bug", "file": file, "template": "* TODO %c %^g\n  %^{Severity|minor|major} since %^t"},
+	})
+	defer func() { captureTemplate, captureVars = "", nil }()
+
+	captureTemplate, captureVars = "b", []string{"Tags=bug", "Date=2026-01-05"}
+	if out := runCmd(captureCmd, "Crash"); !strings.Contains(out, "Captured to "+file) {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	want := "* Existing :home:\n* TODO Crash :bug:\n  minor since <2026-01-05 Mon>\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, want)
+	}
+
+	captureVars = []string{"Tags"}
+	if out := runCmd(captureCmd, "Crash"); !strings.Contains(out, `Error: invalid --var "Tags"`) {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
+
+func TestTerminalPrompter(t *testing.T) {
+	var out bytes.Buffer
+	p := &terminalPrompter{
+		answers: capture.Answers{"Given": "value"},
+		in:      bufio.NewReader(strings.NewReader("\nwork\n")),
+		out:     &out,
+		tags:    func() []string { return []string{"home", "work"} },
+	}
+	if got, err := p.Ask(capture.Prompt{Kind: capture.PromptText, Name: "Given"}); err != nil || got != "value" {
+		t.Errorf("Ask(Given) = %q, %v", got, err)
+	}
+	if got, err := p.Ask(capture.Prompt{Kind: capture.PromptText, Name: "Status", Choices: []string{"open", "closed"}}); err != nil || got != "open" {
+		t.Errorf("Ask(Status) = %q, %v", got, err)
+	}
+	if got, err := p.Ask(capture.Prompt{Kind: capture.PromptTags, Name: "Tags"}); err != nil || got != "work" {
+		t.Errorf("Ask(Tags) = %q, %v", got, err)
+	}
+	if want := "(open, closed)\nStatus [open]: (home, work)\nTags: "; out.String() != want {
+		t.Errorf("Unexpected prompts: %q", out.String())
+	}
+	if _, err := p.Ask(capture.Prompt{Kind: capture.PromptText, Name: "More"}); err == nil {
+		t.Error("Expected an error once the input is exhausted")
+	}
+}
+
+func TestCaptureEscapes(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "notes.org")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	viper.Set("capture.templates", []map[string]interface{}{
+		{"key": "l", "description": "Link", "file": file, "template": "* %c\n  %a in %f%(echo shell)"},
+	})
+	defer func() { captureTemplate, captureLink = "", "" }()
+
+	captureTemplate, captureLink = "l", "file:/src/main.go::42"
+	if out := runCmd(captureCmd, "Look", "at", "%t"); !strings.Contains(out, "shell escapes such as %(echo shell) are disabled") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+
+	viper.Set("capture.shell_escapes", true)
+	if out := runCmd(captureCmd, "Look", "at", "%t"); !strings.Contains(out, "Captured to "+file) {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	want := "* Look at %t\n  [[file:/src/main.go::42]] in notes.orgshell\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, want)
+	}
+}
+
+// withStdin makes standard input read text, as if piped to the command, and
+// returns the file it reads from.
+func withStdin(t *testing.T, text string) *os.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		f.Close()
+	})
+	return f
+}
+
+func TestCaptureStdin(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "log.org")
+	if err := os.WriteFile(file, []byte("* Log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	viper.Set("capture.templates", []map[string]interface{}{
+		{"key": "l", "description": "Log", "file": file, "headline": "Log", "template": "* %c\n  #+begin_example\n  %i\n  #+end_example"},
+		{"key": "n", "description": "Note", "file": file, "headline": "Log"},
+	})
+	defer func() { captureTemplate = "" }()
+
+	captureTemplate = "l"
+	withStdin(t, "commit abc\n\n    Fix the parser\n")
+	if out := runCmd(captureCmd, "-", "Commit"); !strings.Contains(out, "Captured to "+file) {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	want := "* Log\n** Commit\n  #+begin_example\n  commit abc\n\n      Fix the parser\n  #+end_example\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, want)
+	}
+
+	captureTemplate = "n"
+	withStdin(t, "Read the docs\nSection 3 first\n")
+	runCmd(captureCmd)
+	want += "** Read the docs\n  Section 3 first\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, want)
+	}
+
+	// With content given as arguments, piped input is left for the caller.
+	stdin := withStdin(t, "two\nthree\n")
+	runCmd(captureCmd, "one")
+	want += "** one\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, want)
+	}
+	if rest, _ := io.ReadAll(stdin); string(rest) != "two\nthree\n" {
+		t.Errorf("Standard input was consumed, left %q", rest)
+	}
+
+	withStdin(t, "\x7fELF\x00\x00")
+	if out := runCmd(captureCmd, "-"); !strings.Contains(out, "Error: the input is not text") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	withStdin(t, "")
+	if out := runCmd(captureCmd); !strings.Contains(out, "Error: nothing to capture") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
+
+func TestCaptureUnderID(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	inbox := filepath.Join(dir, "inbox.org")
+	projects := filepath.Join(dir, "projects.org")
+	if err := os.WriteFile(inbox, []byte("* Inbox\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projects, []byte("* Website\n:PROPERTIES:\n:ID: website\n:END:\n* Mobile\n:PROPERTIES:\n:CUSTOM_ID: mobile\n:END:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{inbox, projects})
+	viper.Set("default_file", inbox)
+	viper.Set("capture.templates", []map[string]interface{}{
+		{"key": "m", "description": "Meeting", "target": "id:website", "template": "* Meeting: %c"},
+		{"key": "n", "description": "Note", "type": "item", "template": "- %c (from %f)"},
+	})
+	defer func() { captureTemplate, captureUnder = "", "" }()
+
+	captureTemplate = "m"
+	if out := runCmd(captureCmd, "Kickoff"); !strings.Contains(out, "Captured to "+projects) {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	captureTemplate, captureUnder = "n", "#mobile"
+	if out := runCmd(captureCmd, "Ask about push"); !strings.Contains(out, "Captured to "+projects) {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	want := "* Website\n:PROPERTIES:\n:ID: website\n:END:\n** Meeting: Kickoff\n* Mobile\n:PROPERTIES:\n:CUSTOM_ID: mobile\n:END:\n- Ask about push (from projects.org)\n"
+	if data, _ := os.ReadFile(projects); string(data) != want {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, want)
+	}
+
+	captureUnder = "missing"
+	if out := runCmd(captureCmd, "Lost"); !strings.Contains(out, `no task with ID "missing" found`) {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
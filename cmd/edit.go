@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit [file:line|id]",
+	Short: "Edit a task with its body and children in $EDITOR",
+	Long: `Open the subtree of a task (its headline, body and children) in $VISUAL or
+$EDITOR and write the result back in its place when the editor exits.
+
+Nothing is written when the text is left unchanged. If the file was modified
+while the editor was open, the edit is refused and the edited text is kept in
+a temporary file instead.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		svc := newService(viper.GetStringSlice("org_files"))
+		subtree, err := svc.ExtractSubtree(args[0], editOptions())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		text, err := editText(subtree.Text, "org-agenda-*.org")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if text == subtree.Text {
+			fmt.Println("No changes")
+			return
+		}
+
+		t, err := svc.ReplaceSubtree(subtree, text)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			if path, err := saveText(text); err == nil {
+				fmt.Printf("The edited text was saved to %s\n", path)
+			}
+			return
+		}
+		fmt.Printf("Updated %s:%d\n", t.FilePath, t.Line)
+	},
+}
+
+// saveText keeps text that could not be written back in a temporary file and
+// returns its path.
+func saveText(text string) (string, error) {
+	f, err := os.CreateTemp("", "org-agenda-edit-*.org")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.WriteString(text); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	addEditFlags(editCmd)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// setEditor makes editText run a shell script with the given body, which
+// receives the file to edit as $1.
+func setEditor(t *testing.T, script string) {
+	t.Helper()
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+}
+
+func runCmd(cmd *cobra.Command, args ...string) string {
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd.Run(cmd, args)
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	return buf.String()
+}
+
+func TestEdit(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Project\n** TODO Step\n* TODO Next\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+
+	setEditor(t, `sed -i 's/Step/Renamed step/' "$1"`)
+	output := runCmd(editCmd, file+":1")
+	if !strings.Contains(output, "Updated "+file+":1") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* TODO Project\n** TODO Renamed step\n* TODO Next\n" {
+		t.Errorf("Unexpected content: %s", data)
+	}
+
+	setEditor(t, "true")
+	if output := runCmd(editCmd, file+":3"); !strings.Contains(output, "No changes") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+
+	// The file changes while the editor is open.
+	setEditor(t, `sed -i 's/Next/Changed in the editor/' "$1" && printf '* TODO Added elsewhere\n' >> `+file)
+	output = runCmd(editCmd, file+":3")
+	if !strings.Contains(output, "Error: conflict") || !strings.Contains(output, "The edited text was saved to ") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	saved := strings.TrimSpace(output[strings.Index(output, "saved to ")+len("saved to "):])
+	defer func() { _ = os.Remove(saved) }()
+	if text, _ := os.ReadFile(saved); string(text) != "* TODO Changed in the editor\n" {
+		t.Errorf("Unexpected saved text: %q", text)
+	}
+	data, _ = os.ReadFile(file)
+	if string(data) != "* TODO Project\n** TODO Renamed step\n* TODO Next\n* TODO Added elsewhere\n" {
+		t.Errorf("Unexpected content: %s", data)
+	}
+}
+
+func TestAddAndCaptureEdit(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* Inbox\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	viper.Set("default_file", file)
+	viper.Set("capture.format", "* %c")
+	todoEdit, captureEdit = true, true
+	defer func() { todoEdit, captureEdit = false, false }()
+
+	setEditor(t, `printf 'Reviewed\n' >> "$1"`)
+	if output := runCmd(todoAddCmd, "Write report"); !strings.Contains(output, "Added task: Write report") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	if output := runCmd(captureCmd, "Idea"); !strings.Contains(output, "Captured to "+file) {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* Inbox\n* TODO Write report\nReviewed\n* Idea\nReviewed\n" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+
+	setEditor(t, `: > "$1"`)
+	if output := runCmd(todoAddCmd, "Dropped"); !strings.Contains(output, "Error: the entry is empty") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	if output := runCmd(captureCmd, "Dropped"); !strings.Contains(output, "Error: the entry is empty") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+}
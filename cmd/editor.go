@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// editorCommand returns the editor to run: $VISUAL, $EDITOR or vi. The value
+// may carry arguments, e.g. "code --wait".
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// editText lets the user edit text in their editor. The text is written to a
+// temporary file named after pattern (see os.CreateTemp), so that e.g. a
+// ".org" suffix selects the right editor mode, and the edited content is
+// returned once the editor exits.
+func editText(text, pattern string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := f.WriteString(text); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	editor := editorCommand()
+	c := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", editor[0], err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	return string(edited), nil
+}
+
+// stdinIsPiped reports whether standard input is redirected from a file or a
+// pipe rather than attached to a terminal.
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// readText returns the text given as the optional argument in args. It is
+// read from standard input when the argument is "-", or missing while input
+// is piped, and from the editor otherwise.
+func readText(args []string, pattern string) (string, error) {
+	switch {
+	case len(args) > 0 && args[0] != "-":
+		return args[0], nil
+	case len(args) > 0 || stdinIsPiped():
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read standard input: %w", err)
+		}
+		return string(data), nil
+	}
+	return editText("", pattern)
+}
+
+// reviewEntry opens a formatted entry in the editor before it is inserted.
+func reviewEntry(entry string) (string, error) {
+	return editText(entry, "org-agenda-*.org")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/service"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	refileTo          string
+	refileOLP         string
+	refileListTargets bool
+)
+
+var refileCmd = &cobra.Command{
+	Use:   "refile [file:line|id]",
+	Short: "Move a subtree to another file or headline",
+	Long: `Move a task together with its body and children below another headline.
+
+The target is the headline at the --olp outline path ("Projects/Website") in
+the --to file, which defaults to the task's own file. Without --olp the subtree
+becomes a top-level entry at the end of the file. Headline levels are adjusted
+to the new parent, and both files are locked while the subtree is moved.
+
+--list-targets prints the available targets, limited per file by the
+refile_targets setting.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		svc := newService(viper.GetStringSlice("org_files"))
+
+		if refileListTargets {
+			targets, err := svc.ListRefileTargets()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			for _, t := range targets {
+				fmt.Println(t)
+			}
+			return
+		}
+
+		if len(args) != 1 {
+			fmt.Println("Error: specify the task to refile or --list-targets")
+			return
+		}
+
+		target := service.RefileTarget{File: refileTo, OLP: splitOLP(refileOLP)}
+		moved, err := svc.Refile(args[0], target, editOptions())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		target.File = moved.FilePath
+		fmt.Printf("Refiled %s to %s\n", args[0], target)
+	},
+}
+
+// splitOLP splits an outline path such as "Projects/Website" into its
+// headline titles.
+func splitOLP(olp string) []string {
+	var titles []string
+	for _, title := range strings.Split(olp, "/") {
+		if title = strings.TrimSpace(title); title != "" {
+			titles = append(titles, title)
+		}
+	}
+	return titles
+}
+
+func init() {
+	rootCmd.AddCommand(refileCmd)
+
+	addEditFlags(refileCmd)
+	refileCmd.Flags().StringVar(&refileTo, "to", "", "Target file (defaults to the task's file)")
+	refileCmd.Flags().StringVar(&refileOLP, "olp", "", "Outline path of the target headline, e.g. \"Projects/Website\"")
+	refileCmd.Flags().BoolVar(&refileListTargets, "list-targets", false, "List the available refile targets")
+}
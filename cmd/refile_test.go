@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRefile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	inbox := filepath.Join(dir, "inbox.org")
+	projects := filepath.Join(dir, "projects.org")
+	if err := os.WriteFile(inbox, []byte("* TODO Task\nBody\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projects, []byte("* Projects\n** Website\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{inbox, projects})
+	viper.Set("refile_targets", []map[string]interface{}{{"max_level": 2}})
+	refileTo, refileOLP = projects, "Projects/Website"
+	defer func() { refileTo, refileOLP, refileListTargets = "", "", false }()
+
+	run := func(args ...string) string {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		refileCmd.Run(refileCmd, args)
+
+		_ = w.Close()
+		os.Stdout = old
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	out := run(inbox + ":1")
+	if !strings.Contains(out, "Refiled") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	data, _ := os.ReadFile(projects)
+	if string(data) != "* Projects\n** Website\n*** TODO Task\nBody\n" {
+		t.Errorf("Unexpected target content: %q", data)
+	}
+	data, _ = os.ReadFile(inbox)
+	if string(data) != "" {
+		t.Errorf("Unexpected source content: %q", data)
+	}
+
+	refileListTargets = true
+	out = run()
+	if !strings.Contains(out, projects+":Projects/Website\n") || strings.Contains(out, "Projects/Website/Task") {
+		t.Errorf("Unexpected targets: %s", out)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/garaemon/org-agenda-cli/pkg/config"
+	"github.com/garaemon/org-agenda-cli/pkg/service"
 	"github.com/garaemon/org-agenda-cli/pkg/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -65,3 +67,22 @@ func initConfig() {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
+
+// newService builds a Service over paths that honors the rest of the loaded
+// configuration (priorities and so on).
+func newService(paths []string) *service.Service {
+	cfg := loadConfig()
+	cfg.OrgFiles = paths
+	return service.NewServiceFromConfig(cfg)
+}
+
+// loadConfig returns the loaded configuration, or an empty one with a
+// warning when it cannot be decoded.
+func loadConfig() *config.Config {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load config: %v\n", err)
+		return &config.Config{}
+	}
+	return cfg
+}
@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/garaemon/org-agenda-cli/pkg/mcp"
-	"github.com/garaemon/org-agenda-cli/pkg/service"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -16,15 +15,13 @@ var serveCmd = &cobra.Command{
 	Long:  `Start the MCP server to expose org-agenda-cli functionality via Model Context Protocol.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		paths := viper.GetStringSlice("org_files")
-		defaultFile := viper.GetString("default_file")
 
 		if len(paths) == 0 {
 			// Write warnings to stderr to avoid interfering with MCP stdio transport.
 			fmt.Fprintln(os.Stderr, "Warning: No org files configured.")
 		}
 
-		svc := service.NewService(paths, defaultFile)
-		s := mcp.NewServer(svc)
+		s := mcp.NewServer(newService(paths))
 
 		if err := s.Start(); err != nil {
 			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
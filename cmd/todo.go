@@ -2,15 +2,15 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/garaemon/org-agenda-cli/pkg/config"
-	"github.com/garaemon/org-agenda-cli/pkg/item"
-	"github.com/garaemon/org-agenda-cli/pkg/parser"
+	"github.com/garaemon/org-agenda-cli/pkg/agenda"
+	"github.com/garaemon/org-agenda-cli/pkg/service"
 	"github.com/garaemon/org-agenda-cli/pkg/tui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,6 +18,11 @@ import (
 
 var (
 	todoStatus        string
+	todoNote          string
+	todoRemove        bool
+	todoTagAdd        []string
+	todoTagRemove     []string
+	todoTagSet        []string
 	todoTag           string
 	todoFile          string
 	todoSchedule      string
@@ -27,6 +32,14 @@ var (
 	todoNoInteractive bool
 	todoNoColor       bool
 	todoJSON          bool
+	todoSort          string
+	todoEffort        string
+	todoFingerprint   string
+	todoRelocate      bool
+	todoForce         bool
+	todoBlocked       bool
+	todoActionable    bool
+	todoEdit          bool
 )
 
 // todoCmd represents the todo command
@@ -55,44 +68,34 @@ var todoListCmd = &cobra.Command{
 			}
 		}
 
-		orgFiles := config.ResolveOrgFiles(paths)
-		var allItems []*item.Item
-		for _, file := range orgFiles {
-			content, err := os.ReadFile(file)
-			if err != nil {
-				fmt.Printf("Error reading file %s: %v\n", file, err)
-				continue
-			}
+		if todoBlocked && todoActionable {
+			fmt.Println("Error: --blocked and --actionable are mutually exclusive")
+			return
+		}
 
-			items := parser.ParseString(string(content), file)
-			for _, item := range items {
-				// If a specific status is requested, filter by it.
-				// Otherwise, skip items without any status (i.e., non-TODO headlines)
-				// to ensure the 'todo' command only lists actual tasks.
-				if todoStatus != "" {
-					if item.Status != todoStatus {
-						continue
-					}
-				} else {
-					if item.Status == "" {
-						continue
-					}
-				}
-				// Basic filtering by tag (simple implementation)
-				if todoTag != "" {
-					found := false
-					for _, t := range item.Tags {
-						if t == todoTag {
-							found = true
-							break
-						}
-					}
-					if !found {
-						continue
-					}
-				}
-				allItems = append(allItems, item)
+		sortKeys, err := agenda.ParseSortKeys(todoSort)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		svc := newService(paths)
+		allItems, err := svc.ListTodos(service.ListOptions{
+			Status:     todoStatus,
+			Tag:        todoTag,
+			Effort:     todoEffort,
+			Sort:       sortKeys,
+			Blocked:    todoBlocked,
+			Actionable: todoActionable,
+		})
+		var readErrs service.ReadErrors
+		if errors.As(err, &readErrs) {
+			for _, e := range readErrs {
+				fmt.Printf("Error reading file %s: %v\n", e.File, e.Err)
 			}
+		} else if err != nil {
+			fmt.Printf("Error listing todos: %v\n", err)
+			return
 		}
 
 		if todoJSON {
@@ -122,6 +125,7 @@ var todoListCmd = &cobra.Command{
 			stylePriorityC = lipgloss.NewStyle().Foreground(lipgloss.Color("46"))             // Green
 			styleStatus    = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true) // Pink
 			styleFile      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))            // Grey
+			styleBlocked   = lipgloss.NewStyle().Faint(true)
 		)
 
 		for _, item := range allItems {
@@ -138,19 +142,27 @@ var todoListCmd = &cobra.Command{
 			titleStr := item.Title
 			fileStr := fmt.Sprintf("(%s:%d)", item.FilePath, item.LineNumber)
 
+			blocked := len(item.BlockedBy) > 0
 			line := ""
 			if todoNoColor {
 				line = fmt.Sprintf("[%s] %s%s %s", statusStr, priorityStr, titleStr, fileStr)
+			} else if blocked {
+				// Blocked tasks are dimmed instead of highlighted.
+				line = styleBlocked.Render(fmt.Sprintf("[%s] %s%s %s", statusStr, priorityStr, titleStr, fileStr))
 			} else {
 				// Apply colors
+				// Color relative to the scale of the item's file so that e.g.
+				// 1..9 is highlighted the same way as A..C.
 				pStyle := lipgloss.NewStyle()
-				switch item.Priority {
-				case "A":
-					pStyle = stylePriorityA
-				case "B":
-					pStyle = stylePriorityB
-				case "C":
-					pStyle = stylePriorityC
+				if item.Priority != "" {
+					switch item.Priority {
+					case item.Priorities.Highest:
+						pStyle = stylePriorityA
+					case item.Priorities.Lowest:
+						pStyle = stylePriorityC
+					default:
+						pStyle = stylePriorityB
+					}
 				}
 
 				sStr := styleStatus.Render("[" + statusStr + "]")
@@ -165,11 +177,16 @@ var todoListCmd = &cobra.Command{
 				tagsStr := fmt.Sprintf(" :%s:", strings.Join(item.Tags, ":"))
 				if todoNoColor {
 					line += tagsStr
+				} else if blocked {
+					line += styleBlocked.Render(tagsStr)
 				} else {
 					// Use standard cyan (6) instead of 86 for better compatibility
 					line += lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Render(tagsStr)
 				}
 			}
+			if blocked && todoNoColor {
+				line += " (blocked)"
+			}
 
 			fmt.Println(line)
 		}
@@ -182,146 +199,335 @@ var todoAddCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		title := args[0]
-		targetFile := todoFile
-		if targetFile == "" {
-			targetFile = viper.GetString("default_file")
-		}
-		if targetFile == "" {
-			orgFiles := viper.GetStringSlice("org_files")
-			if len(orgFiles) > 0 {
-				targetFile = orgFiles[0]
-			}
-		}
-
-		if targetFile == "" {
+		svc := newService(viper.GetStringSlice("org_files"))
+		targetFile, err := svc.TargetFile(todoFile)
+		if err != nil {
 			fmt.Println("Error: No target file specified and no default file configured.")
 			return
 		}
 
-		f, err := os.OpenFile(targetFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			fmt.Printf("Error opening file: %v\n", err)
+		opts := service.AddOptions{
+			File:     targetFile,
+			Priority: todoPriority,
+			Tags: strings.FieldsFunc(todoTags, func(r rune) bool {
+				return r == ',' || r == ':'
+			}),
+			Schedule: todoSchedule,
+			Deadline: todoDeadline,
+		}
+		if todoEdit {
+			opts.Review = reviewEntry
+		}
+		if err := svc.AddTodo(title, opts); err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
-		defer func() {
-			if err := f.Close(); err != nil {
-				fmt.Printf("Error closing file: %v\n", err)
-			}
-		}()
 
-		content := fmt.Sprintf("* TODO %s", title)
-		if todoPriority != "" {
-			// Org-mode accommodates any character for priority, with A-C as standard. Assuming any string is valid.
-			// Format: * TODO [#A] Title
-			content = fmt.Sprintf("* TODO [#%s] %s", todoPriority, title)
-		}
+		fmt.Printf("Added task: %s to %s\n", title, targetFile)
+	},
+}
 
-		if todoTags != "" {
-			content += fmt.Sprintf(" :%s:", todoTags)
-		}
-		content += "\n"
+var todoDoneCmd = &cobra.Command{
+	Use:   "done [file:line|id]",
+	Short: "Mark a task as DONE",
+	Long: `Mark a task as DONE.
 
-		if todoSchedule != "" {
-			content += fmt.Sprintf("SCHEDULED: <%s>\n", todoSchedule)
-		}
-		if todoDeadline != "" {
-			content += fmt.Sprintf("DEADLINE: <%s>\n", todoDeadline)
-		}
+The task is addressed either by its position ("file:line") or by the value of
+its :ID: or :CUSTOM_ID: property, which keeps working when the file changes.
 
-		if _, err := f.WriteString(content); err != nil {
-			fmt.Printf("Error writing to file: %v\n", err)
+A task with open children, open earlier siblings below an ":ORDERED: t" parent
+or open tasks named in its :BLOCKER: property is blocked and is only completed
+with --force.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		svc := newService(viper.GetStringSlice("org_files"))
+		if err := svc.MarkDone(args[0], editOptions()); err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("Added task: %s to %s\n", title, targetFile)
+		fmt.Printf("Marked task as DONE: %s\n", args[0])
 	},
 }
 
-var todoDoneCmd = &cobra.Command{
-	Use:   "done [id|index]",
-	Short: "Mark a task as DONE",
-	Args:  cobra.ExactArgs(1),
+var todoStateCmd = &cobra.Command{
+	Use:   "state [file:line|id] [KEYWORD]",
+	Short: "Move a task to another TODO keyword",
+	Long: `Move a task to any configured TODO keyword, given by name or fast-access key.
+
+Entering a done state sets CLOSED and leaving one removes it. Keywords declared
+with logging markers, e.g. "WAIT(w@/!)" or "DONE(d!)" in todo_keywords or
+#+TODO, record the change in the entry (in the drawer named by
+log_into_drawer), together with the --note if one is given. Like "todo done",
+moving a blocked task to a done keyword requires --force.`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		arg := args[0]
-		pos, err := parser.ParseFilePosition(arg)
+		svc := newService(viper.GetStringSlice("org_files"))
+		state, err := svc.SetState(args[0], args[1], todoNote, editOptions())
 		if err != nil {
-			fmt.Printf("Error parsing argument: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		filePath := pos.FilePath
-		lineIdx := pos.Line
+		fmt.Printf("Changed task state to %s: %s\n", state, args[0])
+	},
+}
+
+var todoNoteCmd = &cobra.Command{
+	Use:   "note [file:line|id] [text]",
+	Short: "Add a timestamped note to a task",
+	Long: `Add a "Note taken on" entry to a task, like Org's org-add-note.
 
-		// Read file content
-		content, err := os.ReadFile(filePath)
+The note goes into the task's log drawer (log_into_drawer, or LOGBOOK) or, with
+note_location set to "body", to the end of its body. Without a text argument,
+or with "-", the note is read from standard input when it is piped and from
+$VISUAL or $EDITOR otherwise. Notes may span several lines.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		text, err := readText(args[1:], "note-*.txt")
 		if err != nil {
-			fmt.Printf("Error reading file %s: %v\n", filePath, err)
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		lines := strings.Split(string(content), "\n")
-		if lineIdx < 1 || lineIdx > len(lines) {
-			fmt.Printf("Line number %d is out of range for file %s\n", lineIdx, filePath)
+		svc := newService(viper.GetStringSlice("org_files"))
+		if err := svc.AddNote(args[0], text, editOptions()); err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		// Adjust for 0-based index
-		targetLine := lines[lineIdx-1]
+		fmt.Printf("Added note to %s\n", args[0])
+	},
+}
 
-		// Check if it's a TODO item
-		// We expect a line starting with * and containing TODO
-		// Regex might be safer, but let's try simple replacement first as per plan "Change TODO to DONE"
-		// However, we should be careful not to replace "TODO" in the title.
-		// The format is usually "* TODO Title..." or "* TODO [...]"
-		// Let's use the parser's regex logic or similar string manipulation.
-		// A simple approach: Replace first occurrence of " TODO " with " DONE " after the initial asterisks.
+var todoPropCmd = &cobra.Command{
+	Use:   "prop [file:line|id] set|delete|get KEY [VALUE]",
+	Short: "Set, delete or print a property of a task",
+	Long: `Set, delete or print a property of a task's :PROPERTIES: drawer.
+
+"set" creates the drawer right after the planning line when the task has none,
+"delete" removes the drawer once it is empty, and "get" prints the value alone
+so that it can be used in scripts. Property names are case-insensitive.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("requires a task, an action and a property name")
+		}
+		want := 3
+		if args[1] == "set" {
+			want = 4
+		} else if args[1] != "delete" && args[1] != "get" {
+			return fmt.Errorf("unknown action %q (expected set, delete or get)", args[1])
+		}
+		if len(args) != want {
+			return fmt.Errorf("%s expects %d arguments, got %d", args[1], want, len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		svc := newService(viper.GetStringSlice("org_files"))
+		ref, action, key := args[0], args[1], args[2]
 
-		if !strings.Contains(targetLine, item.StatusTodo) {
-			fmt.Printf("Line does not appear to be a %s item.\n", item.StatusTodo)
-			return
+		switch action {
+		case "get":
+			value, err := svc.GetProperty(ref, key)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(value)
+		case "set":
+			if err := svc.SetProperty(ref, key, args[3], editOptions()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Set %s of %s to %s\n", key, ref, args[3])
+		case "delete":
+			if err := svc.DeleteProperty(ref, key, editOptions()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Deleted %s of %s\n", key, ref)
 		}
+	},
+}
 
-		// more robust replacement: look for "* TODO" or "* ... TODO"
-		// But usually it is "* TODO" or "** TODO".
-		// Let's replace " TODO " with " DONE ".
-		// If the task status is immediately after stars, it might be "* TODO".
+var todoScheduleCmd = &cobra.Command{
+	Use:   "schedule [file:line|id] [date]",
+	Short: "Set or remove the SCHEDULED date of a task",
+	Long: `Set or remove the SCHEDULED date of a task.
 
-		newLine := strings.Replace(targetLine, " "+item.StatusTodo+" ", " "+item.StatusDone+" ", 1)
+The date is an absolute date (2026-01-05) or relative Org-style input such as
+"+2d", "fri", "next mon" or "+1w 10:00". Repeaters of the previous date are
+kept. Changes are logged when log_reschedule is set or a --note is given.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPlanningCmd(args, "SCHEDULED")
+	},
+}
+
+var todoDeadlineCmd = &cobra.Command{
+	Use:   "deadline [file:line|id] [date]",
+	Short: "Set or remove the DEADLINE date of a task",
+	Long: `Set or remove the DEADLINE date of a task.
+
+Dates are read like for "todo schedule". Changes are logged when
+log_redeadline is set or a --note is given.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runPlanningCmd(args, "DEADLINE")
+	},
+}
 
-		// If no change occurred, it might be due to strict spacing requirements.
-		if newLine == targetLine {
-			fmt.Printf("Could not find ' %s ' pattern to replace.\n", item.StatusTodo)
+var todoPriorityCmd = &cobra.Command{
+	Use:   "priority [file:line|id] [A|B|C|none|up|down]",
+	Short: "Change the priority of a task",
+	Long: `Change the priority cookie of a task.
+
+The priority must lie within the configured (or #+PRIORITIES) range; "none"
+removes the cookie and "up"/"down" move it one step from the current or
+default priority.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		svc := newService(viper.GetStringSlice("org_files"))
+		priority, err := svc.SetPriority(args[0], args[1], editOptions())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		lines[lineIdx-1] = newLine
-		newContent := strings.Join(lines, "\n")
+		if priority == "" {
+			fmt.Printf("Removed priority from %s\n", args[0])
+			return
+		}
+		fmt.Printf("Set priority of %s to %s\n", args[0], priority)
+	},
+}
+
+var todoTagCmd = &cobra.Command{
+	Use:   "tag [file:line|id]",
+	Short: "Add, remove or replace the tags of a task",
+	Long: `Add, remove or replace the tags of a task.
+
+--set replaces all tags (an empty value clears them) before --add and
+--remove apply. Tags are aligned according to tags_column.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		change := service.TagChange{
+			Replace: cmd.Flags().Changed("set"),
+			Set:     todoTagSet,
+			Add:     todoTagAdd,
+			Remove:  todoTagRemove,
+		}
+		if !change.Replace && len(change.Add) == 0 && len(change.Remove) == 0 {
+			fmt.Println("Error: specify --add, --remove or --set")
+			return
+		}
 
-		if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-			fmt.Printf("Error writing file %s: %v\n", filePath, err)
+		svc := newService(viper.GetStringSlice("org_files"))
+		tags, err := svc.EditTags(args[0], change, editOptions())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("Marked task as DONE: %s\n", filePath)
+		if len(tags) == 0 {
+			fmt.Printf("Removed all tags from %s\n", args[0])
+			return
+		}
+		fmt.Printf("Tags of %s: :%s:\n", args[0], strings.Join(tags, ":"))
 	},
 }
 
+// runPlanningCmd implements "todo schedule" and "todo deadline". The date
+// may span several arguments, e.g. "next mon".
+func runPlanningCmd(args []string, key string) {
+	date := strings.Join(args[1:], " ")
+	if (date == "") == !todoRemove {
+		fmt.Println("Error: specify either a date or --remove")
+		return
+	}
+
+	svc := newService(viper.GetStringSlice("org_files"))
+	set := svc.Reschedule
+	if key == "DEADLINE" {
+		set = svc.SetDeadline
+	}
+	ts, err := set(args[0], date, todoNote, editOptions())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if ts == "" {
+		fmt.Printf("Removed %s from %s\n", key, args[0])
+		return
+	}
+	fmt.Printf("Set %s of %s to %s\n", key, args[0], ts)
+}
+
+// editOptions returns the guard given on the command line for a mutation.
+func editOptions() service.EditOptions {
+	return service.EditOptions{
+		Fingerprint: todoFingerprint,
+		Relocate:    todoRelocate,
+		Force:       todoForce,
+	}
+}
+
+// addEditFlags registers the flags that guard a mutation against stale
+// positions.
+func addEditFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&todoFingerprint, "fingerprint", "", "Reject the change if the task no longer matches this fingerprint (from 'todo list --json')")
+	cmd.Flags().BoolVar(&todoRelocate, "relocate", false, "Find the task by its fingerprint if it moved within the file")
+}
+
 func init() {
 	rootCmd.AddCommand(todoCmd)
 
 	todoCmd.AddCommand(todoListCmd)
 	todoCmd.AddCommand(todoAddCmd)
 	todoCmd.AddCommand(todoDoneCmd)
+	todoCmd.AddCommand(todoStateCmd)
+	todoCmd.AddCommand(todoNoteCmd)
+	todoCmd.AddCommand(todoPropCmd)
+	todoCmd.AddCommand(todoScheduleCmd)
+	todoCmd.AddCommand(todoDeadlineCmd)
+	todoCmd.AddCommand(todoPriorityCmd)
+	todoCmd.AddCommand(todoTagCmd)
 
 	todoListCmd.Flags().StringVar(&todoStatus, "status", "", "Filter by status (TODO|WAITING|DONE)")
 	todoListCmd.Flags().StringVar(&todoTag, "tag", "", "Filter by tag")
 	todoListCmd.Flags().BoolVar(&todoNoInteractive, "no-pager", false, "Disable interactive TUI mode")
 	todoListCmd.Flags().BoolVar(&todoNoColor, "no-color", false, "Disable colored output")
 	todoListCmd.Flags().BoolVar(&todoJSON, "json", false, "Output in JSON format")
+	todoListCmd.Flags().StringVar(&todoEffort, "effort", "", "Filter by effort, e.g. \"<1:00\" or \">=2h\"")
+	todoListCmd.Flags().BoolVar(&todoBlocked, "blocked", false, "Only show tasks blocked by open dependencies")
+	todoListCmd.Flags().BoolVar(&todoActionable, "actionable", false, "Hide tasks blocked by open dependencies")
+	todoListCmd.Flags().StringVar(&todoSort, "sort", "", "Comma-separated sort keys, prefix with '-' to reverse (priority|date|scheduled|deadline|title|status|file; default: priority,date)")
+
+	addEditFlags(todoDoneCmd)
+	addEditFlags(todoStateCmd)
+	for _, c := range []*cobra.Command{todoDoneCmd, todoStateCmd} {
+		c.Flags().BoolVar(&todoForce, "force", false, "Complete the task even if it is blocked")
+	}
+	todoStateCmd.Flags().StringVar(&todoNote, "note", "", "Note to log with the state change")
+	addEditFlags(todoNoteCmd)
+	addEditFlags(todoPropCmd)
+	addEditFlags(todoPriorityCmd)
+	addEditFlags(todoTagCmd)
+	todoTagCmd.Flags().StringSliceVar(&todoTagAdd, "add", nil, "Tags to add (comma-separated)")
+	todoTagCmd.Flags().StringSliceVar(&todoTagRemove, "remove", nil, "Tags to remove (comma-separated)")
+	todoTagCmd.Flags().StringSliceVar(&todoTagSet, "set", nil, "Replace all tags (comma-separated)")
+	for _, c := range []*cobra.Command{todoScheduleCmd, todoDeadlineCmd} {
+		addEditFlags(c)
+		c.Flags().BoolVar(&todoRemove, "remove", false, "Remove the date instead of setting it")
+		c.Flags().StringVar(&todoNote, "note", "", "Note to log with the change")
+	}
 
 	todoAddCmd.Flags().StringVar(&todoFile, "file", "", "Specify the target file")
-	todoAddCmd.Flags().StringVar(&todoSchedule, "schedule", "", "Set a SCHEDULED timestamp")
-	todoAddCmd.Flags().StringVar(&todoDeadline, "deadline", "", "Set a DEADLINE timestamp")
+	todoAddCmd.Flags().StringVar(&todoSchedule, "schedule", "", "Set a SCHEDULED date (YYYY-MM-DD or relative input such as tomorrow, fri or +2d 15:00)")
+	todoAddCmd.Flags().StringVar(&todoDeadline, "deadline", "", "Set a DEADLINE date (YYYY-MM-DD or relative input such as tomorrow, fri or +2d 15:00)")
 	todoAddCmd.Flags().StringVar(&todoTags, "tags", "", "Set tags (comma-separated)")
 	todoAddCmd.Flags().StringVar(&todoPriority, "priority", "", "Set priority (A, B, C)")
+	todoAddCmd.Flags().BoolVar(&todoEdit, "edit", false, "Review the formatted entry in $EDITOR before adding it")
 }
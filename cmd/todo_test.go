@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
@@ -71,6 +73,8 @@ func TestTodoList(t *testing.T) {
 }
 
 func TestTodoAddPriority(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
 	// Setup temporary org file
 	tmpfile, err := os.CreateTemp("", "test*.org")
 	if err != nil {
@@ -309,3 +313,528 @@ func TestTodoListJSON(t *testing.T) {
 		t.Errorf("Output should contain tags field, got: %s", output)
 	}
 }
+
+func TestTodoListSort(t *testing.T) {
+	content := `* TODO [#C] Low Task
+* TODO No Priority Task
+* TODO [#A] Urgent Task
+`
+	tmpfile, err := os.CreateTemp("", "test*.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+	}()
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	_ = tmpfile.Close()
+
+	viper.Reset()
+	viper.Set("org_files", []string{tmpfile.Name()})
+
+	todoNoInteractive = true
+	todoNoColor = true
+	todoJSON = false
+	defer func() { todoSort = "" }()
+
+	run := func() string {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		todoListCmd.Run(todoListCmd, []string{})
+
+		_ = w.Close()
+		os.Stdout = old
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	// Default: by effective priority, the unprioritized task counts as B.
+	output := run()
+	urgent := strings.Index(output, "Urgent Task")
+	none := strings.Index(output, "No Priority Task")
+	low := strings.Index(output, "Low Task")
+	if !(urgent < none && none < low) {
+		t.Errorf("Expected priority order A, B(default), C, got:\n%s", output)
+	}
+
+	todoSort = "-priority"
+	output = run()
+	if strings.Index(output, "Low Task") > strings.Index(output, "Urgent Task") {
+		t.Errorf("Expected reversed priority order, got:\n%s", output)
+	}
+}
+
+func TestTodoListEffortFilter(t *testing.T) {
+	content := `* TODO Quick Task
+:PROPERTIES:
+:EFFORT: 0:30
+:END:
+* TODO Long Task
+:PROPERTIES:
+:EFFORT: 3h
+:END:
+* TODO Unestimated Task
+`
+	tmpfile, err := os.CreateTemp("", "test*.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+	}()
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	_ = tmpfile.Close()
+
+	viper.Reset()
+	viper.Set("org_files", []string{tmpfile.Name()})
+
+	todoNoInteractive = true
+	todoNoColor = true
+	todoJSON = false
+	todoEffort = "<1:00"
+	defer func() { todoEffort = "" }()
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	todoListCmd.Run(todoListCmd, []string{})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Quick Task") {
+		t.Errorf("Output should contain Quick Task, got: %s", output)
+	}
+	if strings.Contains(output, "Long Task") || strings.Contains(output, "Unestimated Task") {
+		t.Errorf("Output should only contain tasks under an hour, got: %s", output)
+	}
+}
+
+func TestTodoListUnreadableFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	unreadable := filepath.Join(dir, "unreadable.org")
+	if err := os.WriteFile(file, []byte("* TODO Readable Task\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(unreadable, []byte("* TODO Hidden Task\n"), 0000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.ReadFile(unreadable); err == nil {
+		t.Skip("file permissions are not enforced for this user")
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{unreadable, file})
+
+	todoNoInteractive = true
+	todoNoColor = true
+	todoJSON = false
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	todoListCmd.Run(todoListCmd, []string{})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Error reading file "+unreadable+":") {
+		t.Errorf("Output should report the missing file, got: %s", output)
+	}
+	if !strings.Contains(output, "Readable Task") {
+		t.Errorf("Output should still list the readable file, got: %s", output)
+	}
+}
+
+func TestTodoDoneByID(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	content := "* TODO First\n* TODO Second\n:PROPERTIES:\n:ID: second-task\n:END:\n"
+	tmpfile, err := os.CreateTemp("", "test*.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Remove(tmpfile.Name())
+	}()
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	_ = tmpfile.Close()
+
+	viper.Reset()
+	viper.Set("org_files", []string{tmpfile.Name()})
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	todoDoneCmd.Run(todoDoneCmd, []string{"second-task"})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Marked task as DONE") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+
+	data, _ := os.ReadFile(tmpfile.Name())
+	if !strings.Contains(string(data), "* TODO First\n* DONE Second") {
+		t.Errorf("Expected only the second task to be DONE, got: %s", data)
+	}
+}
+
+func TestTodoState(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\n:PROPERTIES:\n:ID: task-1\n:END:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	viper.Set("todo_keywords", []string{"TODO WAIT(w@) | DONE(d) CANCELED(c)"})
+	viper.Set("log_into_drawer", true)
+	todoNote = "Waiting for review"
+	defer func() { todoNote = "" }()
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	todoStateCmd.Run(todoStateCmd, []string{"task-1", "w"})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Changed task state to WAIT") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+
+	data, _ := os.ReadFile(file)
+	got := string(data)
+	if !strings.HasPrefix(got, "* WAIT Task\n:PROPERTIES:\n:ID: task-1\n:END:\n:LOGBOOK:\n- State \"WAIT\"       from \"TODO\"") {
+		t.Errorf("Expected a LOGBOOK entry for the state change, got: %s", got)
+	}
+	if !strings.Contains(got, "  Waiting for review\n:END:\n") {
+		t.Errorf("Expected the note in the LOGBOOK, got: %s", got)
+	}
+}
+
+func TestTodoDeadline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	todoDeadlineCmd.Run(todoDeadlineCmd, []string{file + ":1", "2026-01-09", "17:00"})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Set DEADLINE of "+file+":1 to <2026-01-09 Fri 17:00>") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* TODO Task\nDEADLINE: <2026-01-09 Fri 17:00>\n" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+}
+
+func TestTodoAddCanonicalTimestamps(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+
+	viper.Reset()
+	todoFile = file
+	todoPriority = ""
+	todoSchedule = "2026-01-05 10am"
+	todoDeadline = "<2026-01-09 Fri>"
+	defer func() {
+		todoFile, todoSchedule, todoDeadline = "", "", ""
+	}()
+
+	old := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	todoAddCmd.Run(todoAddCmd, []string{"Dated Task"})
+	_ = w.Close()
+	os.Stdout = old
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "* TODO Dated Task\nSCHEDULED: <2026-01-05 Mon 10:00> DEADLINE: <2026-01-09 Fri>\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
+
+func TestTodoTag(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task :old:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	viper.Set("tags_column", 0)
+	if err := todoTagCmd.Flags().Set("set", "a,b"); err != nil {
+		t.Fatal(err)
+	}
+	todoTagAdd = []string{"c"}
+	defer func() {
+		todoTagSet, todoTagAdd = nil, nil
+		todoTagCmd.Flags().Lookup("set").Changed = false
+	}()
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	todoTagCmd.Run(todoTagCmd, []string{file + ":1"})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	if !strings.Contains(buf.String(), ":a:b:c:") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+
+	data, _ := os.ReadFile(file)
+	if string(data) != "* TODO Task :a:b:c:\n" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+}
+
+func TestTodoBlocked(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Parent\n** TODO Child\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	todoNoInteractive = true
+	todoNoColor = true
+	defer func() {
+		todoBlocked = false
+		todoForce = false
+	}()
+
+	run := func(cmd *cobra.Command, args ...string) string {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		cmd.Run(cmd, args)
+
+		_ = w.Close()
+		os.Stdout = old
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	todoBlocked = true
+	output := run(todoListCmd)
+	if !strings.Contains(output, "Parent") || !strings.Contains(output, "(blocked)") || strings.Contains(output, "Child") {
+		t.Errorf("Expected only the parent to be listed as blocked, got: %s", output)
+	}
+
+	output = run(todoDoneCmd, file+":1")
+	if !strings.Contains(output, "Error: task is blocked by "+file+":2") {
+		t.Errorf("Expected the blocked task to be refused, got: %s", output)
+	}
+
+	todoForce = true
+	output = run(todoDoneCmd, file+":1")
+	if !strings.Contains(output, "Marked task as DONE") {
+		t.Errorf("Expected --force to complete the task, got: %s", output)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.HasPrefix(string(data), "* DONE Parent\nCLOSED: ") {
+		t.Errorf("Unexpected content: %s", data)
+	}
+}
+
+func TestTodoNote(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\n:PROPERTIES:\n:ID: task-1\n:END:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	editor := filepath.Join(dir, "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\nprintf 'From the editor\\n' > \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+
+	run := func(stdin string, args ...string) string {
+		oldIn, oldOut := os.Stdin, os.Stdout
+		inR, inW, _ := os.Pipe()
+		_, _ = inW.WriteString(stdin)
+		_ = inW.Close()
+		os.Stdin = inR
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		todoNoteCmd.Run(todoNoteCmd, args)
+
+		_ = w.Close()
+		os.Stdin, os.Stdout = oldIn, oldOut
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	if output := run("", "task-1", "From the argument"); !strings.Contains(output, "Added note to task-1") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	if output := run("Piped line 1\nPiped line 2\n", "task-1", "-"); !strings.Contains(output, "Added note to task-1") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+
+	// Without a text argument and without piped input the editor is used.
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = devNull.Close() }()
+	oldIn, oldOut := os.Stdin, os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdin, os.Stdout = devNull, w
+	todoNoteCmd.Run(todoNoteCmd, []string{"task-1"})
+	_ = w.Close()
+	os.Stdin, os.Stdout = oldIn, oldOut
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	if !strings.Contains(buf.String(), "Added note to task-1") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+
+	data, _ := os.ReadFile(file)
+	got := string(data)
+	for _, want := range []string{
+		":LOGBOOK:\n- Note taken on [",
+		"] \\\\\n  From the editor\n- Note taken on [",
+		"] \\\\\n  Piped line 1\n  Piped line 2\n- Note taken on [",
+		"] \\\\\n  From the argument\n:END:\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestTodoProp(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+
+	run := func(args ...string) string {
+		if err := todoPropCmd.Args(todoPropCmd, args); err != nil {
+			return "Error: " + err.Error()
+		}
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		todoPropCmd.Run(todoPropCmd, args)
+
+		_ = w.Close()
+		os.Stdout = old
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	ref := file + ":1"
+	if output := run(ref, "set", "OWNER", "alice"); !strings.Contains(output, "Set OWNER of "+ref+" to alice") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	if output := run(ref, "get", "owner"); output != "alice\n" {
+		t.Errorf("Expected the bare value, got: %q", output)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:OWNER: alice\n:END:\n" {
+		t.Errorf("Unexpected content: %s", data)
+	}
+	if output := run(ref, "delete", "OWNER"); !strings.Contains(output, "Deleted OWNER of "+ref) {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	if output := run(ref, "get", "OWNER"); !strings.Contains(output, "Error: property OWNER is not set") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	if output := run(ref, "set", "OWNER"); !strings.Contains(output, "Error: set expects 4 arguments") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+	if output := run(ref, "rename", "OWNER"); !strings.Contains(output, "unknown action") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+}
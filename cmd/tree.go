@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/garaemon/org-agenda-cli/pkg/service"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Edit the outline structure",
+	Long: `Edit the outline structure. Every command operates on a whole subtree: the
+headline with its body and all descendants.`,
+}
+
+var treePromoteCmd = &cobra.Command{
+	Use:   "promote [file:line|id]",
+	Short: "Promote a subtree by one level",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTreeCmd(args[0], "Promoted", (*service.Service).PromoteSubtree)
+	},
+}
+
+var treeDemoteCmd = &cobra.Command{
+	Use:   "demote [file:line|id]",
+	Short: "Demote a subtree by one level",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTreeCmd(args[0], "Demoted", (*service.Service).DemoteSubtree)
+	},
+}
+
+var treeMoveUpCmd = &cobra.Command{
+	Use:   "move-up [file:line|id]",
+	Short: "Swap a subtree with its previous sibling",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTreeCmd(args[0], "Moved up", (*service.Service).MoveSubtreeUp)
+	},
+}
+
+var treeMoveDownCmd = &cobra.Command{
+	Use:   "move-down [file:line|id]",
+	Short: "Swap a subtree with its next sibling",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTreeCmd(args[0], "Moved down", (*service.Service).MoveSubtreeDown)
+	},
+}
+
+var treeDeleteCmd = &cobra.Command{
+	Use:   "delete [file:line|id]",
+	Short: "Delete a subtree",
+	Long:  `Delete a headline with its body and descendants. Use 'undo' to bring it back.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		svc := newService(viper.GetStringSlice("org_files"))
+		if _, err := svc.DeleteSubtree(args[0], editOptions()); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Deleted %s\n", args[0])
+	},
+}
+
+// runTreeCmd applies a structural edit to ref and reports where the subtree
+// ended up.
+func runTreeCmd(ref, done string, apply func(*service.Service, string, service.EditOptions) (*service.Target, error)) {
+	svc := newService(viper.GetStringSlice("org_files"))
+	t, err := apply(svc, ref, editOptions())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("%s %s (now at %s:%d)\n", done, ref, t.FilePath, t.Line)
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+
+	for _, c := range []*cobra.Command{treePromoteCmd, treeDemoteCmd, treeMoveUpCmd, treeMoveDownCmd, treeDeleteCmd} {
+		treeCmd.AddCommand(c)
+		addEditFlags(c)
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestTreeDemote(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* A\n* B\n** B1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	treeDemoteCmd.Run(treeDemoteCmd, []string{file + ":2"})
+
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	if !strings.Contains(buf.String(), "Demoted") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+
+	data, _ := os.ReadFile(file)
+	if string(data) != "* A\n** B\n*** B1\n" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	undoList  bool
+	undoSteps int
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the last changes made to Org files",
+	Long: `Revert the last changes made through org-agenda, including the MCP tools.
+
+Every change is recorded in a journal under the XDG state directory
+(~/.local/state/org-agenda-cli/journal.jsonl, or the journal setting). A change
+is only reverted while the files it touched are unchanged since; otherwise
+undo stops and leaves them alone.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		svc := newService(viper.GetStringSlice("org_files"))
+
+		if undoList {
+			entries, err := svc.History()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if len(entries) == 0 {
+				fmt.Println("Nothing to undo")
+				return
+			}
+			for i := len(entries) - 1; i >= 0; i-- {
+				e := entries[i]
+				fmt.Printf("%3d  %s  %s  (%s)\n", len(entries)-i, e.Time.Format("2006-01-02 15:04:05"), e.Op, strings.Join(e.Files(), ", "))
+			}
+			return
+		}
+
+		undone, err := svc.Undo(undoSteps)
+		for _, e := range undone {
+			fmt.Printf("Undid %s\n", e.Op)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if len(undone) == 0 {
+			fmt.Println("Nothing to undo")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "List the changes that can be undone, newest first")
+	undoCmd.Flags().IntVar(&undoSteps, "steps", 1, "Number of changes to undo")
+}
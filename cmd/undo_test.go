@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestUndo(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.Set("org_files", []string{file})
+	defer func() { undoList, undoSteps = false, 1 }()
+
+	run := func(c func()) string {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		c()
+
+		_ = w.Close()
+		os.Stdout = old
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	run(func() { todoDoneCmd.Run(todoDoneCmd, []string{file + ":1"}) })
+
+	undoList = true
+	out := run(func() { undoCmd.Run(undoCmd, nil) })
+	if !strings.Contains(out, "done "+file+":1") {
+		t.Errorf("Unexpected list output: %s", out)
+	}
+
+	undoList = false
+	out = run(func() { undoCmd.Run(undoCmd, nil) })
+	if !strings.Contains(out, "Undid done") {
+		t.Errorf("Unexpected undo output: %s", out)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* TODO Task\n" {
+		t.Errorf("Unexpected content after undo: %q", data)
+	}
+}
@@ -0,0 +1,95 @@
+package agenda
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// DayEffort is the total effort of the open tasks falling on a single day.
+type DayEffort struct {
+	Date   time.Time
+	Effort time.Duration
+}
+
+// SumEffortByDay returns one entry per day in [start, end] with the summed
+// effort of the items planned on that day. An item counts on its scheduled
+// date, or on its deadline when it is not scheduled within the range. Done
+// items are ignored since their effort no longer needs capacity.
+func SumEffortByDay(items []*item.Item, start, end time.Time) []DayEffort {
+	s := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	e := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+
+	var days []DayEffort
+	index := map[time.Time]int{}
+	for d := s; !d.After(e); d = d.AddDate(0, 0, 1) {
+		index[d] = len(days)
+		days = append(days, DayEffort{Date: d})
+	}
+
+	for _, it := range items {
+		if it.EffortMinutes == 0 || it.Done {
+			continue
+		}
+		var date *time.Time
+		switch {
+		case it.Scheduled != nil && isWithin(it.Scheduled, start, end):
+			date = it.Scheduled
+		case it.Deadline != nil && isWithin(it.Deadline, start, end):
+			date = it.Deadline
+		default:
+			continue
+		}
+		key := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		days[index[key]].Effort += time.Duration(it.EffortMinutes) * time.Minute
+	}
+	return days
+}
+
+// ParseEffortFilter parses an expression such as "<1:00", ">=2h" or "=0:30"
+// and returns a predicate over items. Items without an effort, or with one
+// that cannot be parsed, never match.
+func ParseEffortFilter(expr string) (func(*item.Item) bool, error) {
+	expr = strings.TrimSpace(expr)
+	op := "="
+	for _, candidate := range []string{"<=", ">=", "<>", "!=", "<", ">", "="} {
+		if strings.HasPrefix(expr, candidate) {
+			op = candidate
+			expr = expr[len(candidate):]
+			break
+		}
+	}
+
+	limit, err := parser.ParseDuration(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid effort filter: %w", err)
+	}
+	limitMinutes := int(limit / time.Minute)
+
+	return func(it *item.Item) bool {
+		if it.Effort == "" {
+			return false
+		}
+		if _, err := parser.ParseDuration(it.Effort); err != nil {
+			return false
+		}
+		v := it.EffortMinutes
+		switch op {
+		case "<":
+			return v < limitMinutes
+		case "<=":
+			return v <= limitMinutes
+		case ">":
+			return v > limitMinutes
+		case ">=":
+			return v >= limitMinutes
+		case "<>", "!=":
+			return v != limitMinutes
+		default:
+			return v == limitMinutes
+		}
+	}, nil
+}
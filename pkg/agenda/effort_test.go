@@ -0,0 +1,72 @@
+package agenda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+)
+
+func TestSumEffortByDay(t *testing.T) {
+	mon := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	tue := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	items := []*item.Item{
+		{Title: "A", Status: "TODO", Scheduled: &tue, Effort: "8:00", EffortMinutes: 480},
+		{Title: "B", Status: "TODO", Deadline: &tue, Effort: "1:30", EffortMinutes: 90},
+		{Title: "C", Status: "TODO", Scheduled: &mon, Effort: "0:45", EffortMinutes: 45},
+		{Title: "Done", Status: "DONE", Done: true, Scheduled: &mon, Effort: "2:00", EffortMinutes: 120},
+		{Title: "No effort", Status: "TODO", Scheduled: &mon},
+	}
+
+	start := time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 6)
+	days := SumEffortByDay(items, start, end)
+
+	if len(days) != 7 {
+		t.Fatalf("Expected 7 days, got %d", len(days))
+	}
+	if days[1].Effort != 45*time.Minute {
+		t.Errorf("Expected 0:45 on Monday, got %v", days[1].Effort)
+	}
+	if days[2].Effort != 9*time.Hour+30*time.Minute {
+		t.Errorf("Expected 9:30 on Tuesday, got %v", days[2].Effort)
+	}
+	if days[0].Effort != 0 {
+		t.Errorf("Expected no effort on Sunday, got %v", days[0].Effort)
+	}
+}
+
+func TestParseEffortFilter(t *testing.T) {
+	short := &item.Item{Effort: "0:30", EffortMinutes: 30}
+	long := &item.Item{Effort: "2h", EffortMinutes: 120}
+	none := &item.Item{}
+	invalid := &item.Item{Effort: "soon"}
+
+	tests := []struct {
+		expr  string
+		match []bool // short, long, none, invalid
+	}{
+		{"<1:00", []bool{true, false, false, false}},
+		{">=2h", []bool{false, true, false, false}},
+		{"0:30", []bool{true, false, false, false}},
+		{"!=0:30", []bool{false, true, false, false}},
+		{"=0:00", []bool{false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		match, err := ParseEffortFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseEffortFilter(%q) failed: %v", tt.expr, err)
+		}
+		for i, it := range []*item.Item{short, long, none, invalid} {
+			if got := match(it); got != tt.match[i] {
+				t.Errorf("ParseEffortFilter(%q) on item %d = %v, want %v", tt.expr, i, got, tt.match[i])
+			}
+		}
+	}
+
+	if _, err := ParseEffortFilter("<later"); err == nil {
+		t.Error("Expected error for invalid effort filter")
+	}
+}
@@ -0,0 +1,314 @@
+package agenda
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+)
+
+// ParseMatch parses a tags/property match as understood by Org's tags
+// agenda views and returns a predicate over items. The query consists of
+// alternatives separated by "|", each a sequence of terms that must all hold
+// (optionally joined by "&"). A term prefixed with "-" must not hold. Terms
+// are tags ("work"), tag regexps ("{^sprint}") or comparisons of a property
+// with a string, regexp or number ("OWNER=\"bob\"", "TICKET={^OPS-}",
+// "EFFORT_POINTS>=3"). TODO, LEVEL and PRIORITY compare the keyword, level
+// and effective priority of the headline. A final "/" part restricts TODO
+// keywords: "/WAITING|TODO" keeps those keywords, "/-DONE" drops one and a
+// leading "!" ("/!") keeps open tasks only.
+func ParseMatch(query string) (func(*item.Item) bool, error) {
+	tagsPart, todoPart, hasTodo := cutOutside(query, '/')
+
+	var alternatives [][]matchTerm
+	for _, alt := range splitOutside(tagsPart, '|') {
+		terms, err := parseMatchTerms(alt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match %q: %w", query, err)
+		}
+		if len(terms) > 0 {
+			alternatives = append(alternatives, terms)
+		}
+	}
+
+	todoMatch := func(*item.Item) bool { return true }
+	if hasTodo {
+		var err error
+		if todoMatch, err = parseTodoMatch(todoPart); err != nil {
+			return nil, fmt.Errorf("invalid match %q: %w", query, err)
+		}
+	}
+
+	return func(it *item.Item) bool {
+		if !todoMatch(it) {
+			return false
+		}
+		if len(alternatives) == 0 {
+			return true
+		}
+		for _, terms := range alternatives {
+			if matchAll(terms, it) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// matchTerm is one condition of a match; negate inverts it.
+type matchTerm struct {
+	negate bool
+	test   func(*item.Item) bool
+}
+
+func matchAll(terms []matchTerm, it *item.Item) bool {
+	for _, t := range terms {
+		if t.test(it) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	matchNameRegex = regexp.MustCompile(`^[\pL\pN_@#%]+`)
+	matchOps       = []string{"<=", ">=", "<>", "!=", "==", "<", ">", "="}
+)
+
+func parseMatchTerms(s string) ([]matchTerm, error) {
+	var terms []matchTerm
+	s = strings.TrimSpace(s)
+	for s != "" {
+		var t matchTerm
+		switch s[0] {
+		case '&', ' ', '\t':
+			s = s[1:]
+			continue
+		case '-':
+			t.negate = true
+			s = s[1:]
+		case '+':
+			s = s[1:]
+		}
+
+		if strings.HasPrefix(s, "{") {
+			re, rest, err := readRegexp(s)
+			if err != nil {
+				return nil, err
+			}
+			t.test = func(it *item.Item) bool {
+				for _, tag := range it.Tags {
+					if re.MatchString(tag) {
+						return true
+					}
+				}
+				return false
+			}
+			terms = append(terms, t)
+			s = rest
+			continue
+		}
+
+		name := matchNameRegex.FindString(s)
+		if name == "" {
+			return nil, fmt.Errorf("unexpected %q", s)
+		}
+		s = s[len(name):]
+
+		op := ""
+		for _, candidate := range matchOps {
+			if strings.HasPrefix(s, candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			tag := name
+			t.test = func(it *item.Item) bool {
+				for _, have := range it.Tags {
+					if have == tag {
+						return true
+					}
+				}
+				return false
+			}
+			terms = append(terms, t)
+			continue
+		}
+
+		test, rest, err := parseComparison(strings.ToUpper(name), op, s[len(op):])
+		if err != nil {
+			return nil, err
+		}
+		t.test = test
+		terms = append(terms, t)
+		s = rest
+	}
+	return terms, nil
+}
+
+// parseComparison reads the value compared with the property key by op from
+// the start of s and returns the test along with the rest of s.
+func parseComparison(key, op, s string) (func(*item.Item) bool, string, error) {
+	switch {
+	case strings.HasPrefix(s, "{"):
+		re, rest, err := readRegexp(s)
+		if err != nil {
+			return nil, "", err
+		}
+		if op != "=" && op != "==" && op != "<>" && op != "!=" {
+			return nil, "", fmt.Errorf("regexps can only be compared with = or <>")
+		}
+		want := op == "=" || op == "=="
+		return func(it *item.Item) bool {
+			return re.MatchString(matchValue(it, key)) == want
+		}, rest, nil
+
+	case strings.HasPrefix(s, `"`):
+		end := strings.Index(s[1:], `"`)
+		if end == -1 {
+			return nil, "", fmt.Errorf("unterminated string in %q", s)
+		}
+		value := s[1 : end+1]
+		return func(it *item.Item) bool {
+			return compare(op, strings.Compare(matchValue(it, key), value))
+		}, s[end+2:], nil
+	}
+
+	n := strings.IndexAny(s, " \t&|+-")
+	if n == 0 {
+		return nil, "", fmt.Errorf("missing value for %s", key)
+	}
+	if n == -1 {
+		n = len(s)
+	}
+	value, err := strconv.ParseFloat(s[:n], 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("%q is neither a number, a quoted string nor a {regexp}", s[:n])
+	}
+	return func(it *item.Item) bool {
+		have, err := strconv.ParseFloat(strings.TrimSpace(matchValue(it, key)), 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case have < value:
+			return compare(op, -1)
+		case have > value:
+			return compare(op, 1)
+		}
+		return compare(op, 0)
+	}, s[n:], nil
+}
+
+// compare reports whether the outcome cmp of a comparison satisfies op.
+func compare(op string, cmp int) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<>", "!=":
+		return cmp != 0
+	}
+	return cmp == 0
+}
+
+// matchValue returns the value of key for it, "" when it is not set.
+func matchValue(it *item.Item, key string) string {
+	switch key {
+	case "TODO":
+		return it.Status
+	case "LEVEL":
+		return strconv.Itoa(it.Level)
+	case "PRIORITY":
+		if it.EffectivePriority != "" {
+			return it.EffectivePriority
+		}
+		return it.Priority
+	}
+	return it.Properties[key]
+}
+
+// parseTodoMatch parses the part of a match after "/".
+func parseTodoMatch(s string) (func(*item.Item) bool, error) {
+	openOnly := strings.HasPrefix(s, "!")
+	s = strings.TrimPrefix(s, "!")
+
+	include, exclude := map[string]bool{}, map[string]bool{}
+	for _, alt := range strings.Split(s, "|") {
+		for _, field := range strings.FieldsFunc(alt, func(r rune) bool { return r == '+' || r == ' ' }) {
+			for i, word := range strings.Split(field, "-") {
+				switch {
+				case word == "":
+				case i == 0:
+					include[word] = true
+				default:
+					exclude[word] = true
+				}
+			}
+		}
+	}
+
+	return func(it *item.Item) bool {
+		if openOnly && (it.Status == "" || it.Done) {
+			return false
+		}
+		if len(include) > 0 && !include[it.Status] {
+			return false
+		}
+		return !exclude[it.Status]
+	}, nil
+}
+
+// readRegexp reads a "{regexp}" from the start of s and returns it with the
+// rest of s.
+func readRegexp(s string) (*regexp.Regexp, string, error) {
+	end := strings.Index(s, "}")
+	if end == -1 {
+		return nil, "", fmt.Errorf("unterminated regexp in %q", s)
+	}
+	re, err := regexp.Compile(s[1:end])
+	if err != nil {
+		return nil, "", err
+	}
+	return re, s[end+1:], nil
+}
+
+// cutOutside is strings.Cut for the first sep that is not part of a quoted
+// string or a {regexp}.
+func cutOutside(s string, sep byte) (string, string, bool) {
+	parts := splitOutside(s, sep)
+	if len(parts) == 1 {
+		return s, "", false
+	}
+	return parts[0], s[len(parts[0])+1:], true
+}
+
+// splitOutside splits s at every sep that is not part of a quoted string or
+// a {regexp}.
+func splitOutside(s string, sep byte) []string {
+	var parts []string
+	inQuote, inBrace := false, false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' && !inBrace:
+			inQuote = !inQuote
+		case c == '{' && !inQuote:
+			inBrace = true
+		case c == '}' && !inQuote:
+			inBrace = false
+		case c == sep && !inQuote && !inBrace:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
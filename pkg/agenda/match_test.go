@@ -0,0 +1,62 @@
+package agenda
+
+import (
+	"testing"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+)
+
+func TestParseMatch(t *testing.T) {
+	items := []*item.Item{
+		{Title: "Sprint task", Status: "TODO", Level: 1, Tags: []string{"sprint12", "work"}, EffectivePriority: "A",
+			Properties: map[string]string{"OWNER": "bob", "POINTS": "3"}},
+		{Title: "Waiting", Status: "WAITING", Level: 2, Tags: []string{"work"}, EffectivePriority: "B",
+			Properties: map[string]string{"OWNER": "alice", "TICKET": "OPS-12"}},
+		{Title: "Done", Status: "DONE", Done: true, Level: 1, Tags: []string{"sprint11"}, EffectivePriority: "B"},
+		{Title: "Note", Level: 1},
+	}
+
+	tests := []struct {
+		query string
+		match []bool
+	}{
+		{"", []bool{true, true, true, true}},
+		{"work", []bool{true, true, false, false}},
+		{"+work-sprint12", []bool{false, true, false, false}},
+		{"work&sprint12", []bool{true, false, false, false}},
+		{"sprint12|sprint11", []bool{true, false, true, false}},
+		{"{^sprint}", []bool{true, false, true, false}},
+		{`TODO="WAITING"`, []bool{false, true, false, false}},
+		{`OWNER="bob"`, []bool{true, false, false, false}},
+		{`OWNER<>"bob"`, []bool{false, true, true, true}},
+		{"TICKET={^OPS-}", []bool{false, true, false, false}},
+		{"POINTS>=3", []bool{true, false, false, false}},
+		{"POINTS<3", []bool{false, false, false, false}},
+		{"LEVEL=2", []bool{false, true, false, false}},
+		{`PRIORITY="A"`, []bool{true, false, false, false}},
+		{"work/WAITING", []bool{false, true, false, false}},
+		{"/TODO|DONE", []bool{true, false, true, false}},
+		{"/-DONE", []bool{true, true, false, true}},
+		{"/!", []bool{true, true, false, false}},
+		{"/!-WAITING", []bool{true, false, false, false}},
+		{`-work+OWNER<>"x"/!`, []bool{false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		match, err := ParseMatch(tt.query)
+		if err != nil {
+			t.Fatalf("ParseMatch(%q) failed: %v", tt.query, err)
+		}
+		for i, it := range items {
+			if got := match(it); got != tt.match[i] {
+				t.Errorf("ParseMatch(%q) on %q = %v, want %v", tt.query, it.Title, got, tt.match[i])
+			}
+		}
+	}
+
+	for _, query := range []string{`OWNER="bob`, "{sprint", "POINTS>high", "TICKET<{x}", "work*"} {
+		if _, err := ParseMatch(query); err == nil {
+			t.Errorf("Expected an error for %q", query)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+)
+
+// DefaultSortKeys orders items by effective priority, then by date.
+var DefaultSortKeys = []string{"priority", "date"}
+
+// SortKeys lists the keys accepted by SortItems. A key may be prefixed with
+// "-" to reverse its order.
+var SortKeys = []string{"priority", "date", "scheduled", "deadline", "title", "status", "file"}
+
+type lessFunc func(a, b *item.Item) int
+
+// ParseSortKeys parses a comma-separated list of sort keys such as
+// "priority,-date". An empty string yields DefaultSortKeys.
+func ParseSortKeys(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultSortKeys, nil
+	}
+	var keys []string
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if _, err := comparator(k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// SortItems sorts items in place by the given keys. Items that compare equal
+// on every key keep their parsing order.
+func SortItems(items []*item.Item, keys []string) error {
+	var cmps []lessFunc
+	for _, k := range keys {
+		cmp, err := comparator(k)
+		if err != nil {
+			return err
+		}
+		cmps = append(cmps, cmp)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, cmp := range cmps {
+			if c := cmp(items[i], items[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+func comparator(key string) (lessFunc, error) {
+	desc := strings.HasPrefix(key, "-")
+	name := strings.TrimPrefix(key, "-")
+
+	var cmp lessFunc
+	switch name {
+	case "priority":
+		cmp = func(a, b *item.Item) int {
+			return compareStrings(a.EffectivePriority, b.EffectivePriority)
+		}
+	case "date":
+		cmp = func(a, b *item.Item) int {
+			return compareTimes(EarliestDate(a), EarliestDate(b))
+		}
+	case "scheduled":
+		cmp = func(a, b *item.Item) int { return compareTimes(a.Scheduled, b.Scheduled) }
+	case "deadline":
+		cmp = func(a, b *item.Item) int { return compareTimes(a.Deadline, b.Deadline) }
+	case "title":
+		cmp = func(a, b *item.Item) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		}
+	case "status":
+		cmp = func(a, b *item.Item) int { return strings.Compare(a.Status, b.Status) }
+	case "file":
+		cmp = func(a, b *item.Item) int {
+			if c := strings.Compare(a.FilePath, b.FilePath); c != 0 {
+				return c
+			}
+			return a.LineNumber - b.LineNumber
+		}
+	default:
+		return nil, fmt.Errorf("unknown sort key %q (valid keys: %s)", name, strings.Join(SortKeys, ", "))
+	}
+
+	if desc {
+		return func(a, b *item.Item) int { return -cmp(a, b) }, nil
+	}
+	return cmp, nil
+}
+
+// EarliestDate returns the earlier of the item's scheduled and deadline
+// dates, or nil when it has neither.
+func EarliestDate(it *item.Item) *time.Time {
+	switch {
+	case it.Scheduled == nil:
+		return it.Deadline
+	case it.Deadline == nil:
+		return it.Scheduled
+	case it.Deadline.Before(*it.Scheduled):
+		return it.Deadline
+	default:
+		return it.Scheduled
+	}
+}
+
+// compareStrings orders empty values last.
+func compareStrings(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareTimes orders missing dates last.
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
@@ -0,0 +1,90 @@
+package agenda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+)
+
+func titles(items []*item.Item) []string {
+	var res []string
+	for _, it := range items {
+		res = append(res, it.Title)
+	}
+	return res
+}
+
+func TestSortItems_PriorityThenDate(t *testing.T) {
+	d1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	items := []*item.Item{
+		{Title: "B late", EffectivePriority: "B", Scheduled: &d2},
+		{Title: "C", EffectivePriority: "C"},
+		{Title: "B undated", EffectivePriority: "B"},
+		{Title: "A", EffectivePriority: "A"},
+		{Title: "B early", EffectivePriority: "B", Deadline: &d1},
+	}
+
+	if err := SortItems(items, DefaultSortKeys); err != nil {
+		t.Fatalf("SortItems failed: %v", err)
+	}
+
+	expected := []string{"A", "B early", "B late", "B undated", "C"}
+	got := titles(items)
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestSortItems_NumericPriorities(t *testing.T) {
+	items := []*item.Item{
+		{Title: "Five", EffectivePriority: "5"},
+		{Title: "One", EffectivePriority: "1"},
+		{Title: "Nine", EffectivePriority: "9"},
+	}
+	if err := SortItems(items, []string{"priority"}); err != nil {
+		t.Fatalf("SortItems failed: %v", err)
+	}
+	got := titles(items)
+	if got[0] != "One" || got[1] != "Five" || got[2] != "Nine" {
+		t.Errorf("Unexpected order: %v", got)
+	}
+}
+
+func TestSortItems_Reverse(t *testing.T) {
+	items := []*item.Item{
+		{Title: "alpha"},
+		{Title: "Charlie"},
+		{Title: "bravo"},
+	}
+	if err := SortItems(items, []string{"-title"}); err != nil {
+		t.Fatalf("SortItems failed: %v", err)
+	}
+	got := titles(items)
+	if got[0] != "Charlie" || got[1] != "bravo" || got[2] != "alpha" {
+		t.Errorf("Unexpected order: %v", got)
+	}
+}
+
+func TestParseSortKeys(t *testing.T) {
+	keys, err := ParseSortKeys("")
+	if err != nil || len(keys) != 2 || keys[0] != "priority" {
+		t.Errorf("Expected default keys, got %v (err: %v)", keys, err)
+	}
+
+	keys, err = ParseSortKeys("date, -title")
+	if err != nil {
+		t.Fatalf("ParseSortKeys failed: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "date" || keys[1] != "-title" {
+		t.Errorf("Unexpected keys: %v", keys)
+	}
+
+	if _, err := ParseSortKeys("bogus"); err == nil {
+		t.Error("Expected error for unknown sort key")
+	}
+}
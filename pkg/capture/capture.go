@@ -2,31 +2,62 @@ package capture
 
 import (
 	"fmt"
-	"os"
 	"strings"
+	"time"
 
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/orgfile"
 	"github.com/garaemon/org-agenda-cli/pkg/parser"
 )
 
 // Insert appends the entry to the file, respecting the configuration (Heading/OLP).
 func Insert(filePath string, heading string, olp []string, entry string, prepend bool) error {
-	// Read file
-	contentBytes, err := os.ReadFile(filePath)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	tmpl := Template{File: filePath, Headline: heading, OLP: olp, Prepend: prepend}
+	return orgfile.Update(filePath, func(content []byte) ([]byte, error) {
+		output, err := tmpl.Insert(string(content), entry, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return []byte(output), nil
+	})
+}
+
+// findTarget returns the line number of the headline given by heading or
+// olp, or -1 for the root of the file when neither is set.
+func findTarget(lines []string, filePath string, heading string, olp []string) (int, error) {
+	headingIndex := -1
+	if len(olp) > 0 {
+		// Handle OLP
+		headingIndex, _, _ = findOLPInsertionPoint(lines, olp)
+	} else if heading != "" {
+		// Handle single heading
+		headingIndex, _, _ = findHeadingInsertionPoint(lines, heading)
 	}
-	content := string(contentBytes)
 
+	if headingIndex == -1 && (heading != "" || len(olp) > 0) {
+		target := heading
+		if len(olp) > 0 {
+			target = strings.Join(olp, " > ")
+		}
+		return -1, fmt.Errorf("target headline '%s' not found in %s", target, filePath)
+	}
+	return headingIndex, nil
+}
+
+// contentLines splits content into lines, without the empty element left by
+// a trailing newline.
+func contentLines(content string) []string {
 	lines := strings.Split(content, "\n")
 	// Remove last empty line if it exists (result of trailing newline)
 	if len(lines) > 0 && lines[len(lines)-1] == "" {
 		lines = lines[:len(lines)-1]
 	}
-	// Handle case where file is empty
-	if len(lines) == 0 {
-		lines = []string{}
-	}
+	return lines
+}
 
+// insertEntry inserts entry below the headline at headingIndex, or at the
+// top level of the file when headingIndex is -1, and returns the content.
+func insertEntry(lines []string, headingIndex int, entry string, prepend bool) string {
 	// Detect entry level
 	entryLevel := 0
 	for _, line := range strings.Split(entry, "\n") {
@@ -39,31 +70,14 @@ func Insert(filePath string, heading string, olp []string, entry string, prepend
 
 	var insertionLine int
 	var targetLevel int
-	// headingIndex is the line number of the target headline, or -1 if not applicable (root)
-	headingIndex := -1
-
-	if len(olp) > 0 {
-		// Handle OLP
-		headingIndex, insertionLine, targetLevel = findOLPInsertionPoint(lines, olp)
-	} else if heading != "" {
-		// Handle single heading
-		headingIndex, insertionLine, targetLevel = findHeadingInsertionPoint(lines, heading)
-	} else {
+	if headingIndex != -1 {
+		insertionLine = edit.SubtreeEnd(lines, headingIndex)
+		targetLevel = parser.ParseHeadline(lines[headingIndex]).Level
+	} else if prepend {
 		// Default: Append to end or prepend
-		if prepend {
-			insertionLine = 0
-		} else {
-			insertionLine = len(lines)
-		}
-		targetLevel = 0
-	}
-
-	if insertionLine == -1 {
-		target := heading
-		if len(olp) > 0 {
-			target = strings.Join(olp, " > ")
-		}
-		return fmt.Errorf("target headline '%s' not found in %s", target, filePath)
+		insertionLine = 0
+	} else {
+		insertionLine = len(lines)
 	}
 
 	// Refine insertion point for text-only entries (append to immediate body)
@@ -73,17 +87,15 @@ func Insert(filePath string, heading string, olp []string, entry string, prepend
 
 	// Calculate actual insertion point based on prepend
 	finalInsertionLine := insertionLine
-	if (heading != "" || len(olp) > 0) && prepend {
+	if headingIndex != -1 && prepend {
 		// If prepending to a heading, insert right after the heading
-		if headingIndex != -1 {
-			finalInsertionLine = headingIndex + 1
-		}
+		finalInsertionLine = headingIndex + 1
 	}
 
 	// Adjust entry level if we are inserting under a heading
 	adjustedEntry := entry
 	if targetLevel > 0 {
-		adjustedEntry = adjustEntryLevel(entry, targetLevel+1)
+		adjustedEntry = AdjustEntryLevel(entry, targetLevel+1)
 	}
 	adjustedEntry = strings.TrimSuffix(adjustedEntry, "\n")
 
@@ -100,7 +112,7 @@ func Insert(filePath string, heading string, olp []string, entry string, prepend
 		output += "\n"
 	}
 
-	return os.WriteFile(filePath, []byte(output), 0644)
+	return output
 }
 
 func findHeadingInsertionPoint(lines []string, heading string) (int, int, int) {
@@ -109,7 +121,7 @@ func findHeadingInsertionPoint(lines []string, heading string) (int, int, int) {
 		item := parser.ParseHeadline(line)
 		if item != nil && item.Title == heading {
 			// Found.
-			return i, findEndOfSubtree(lines, i, item.Level), item.Level
+			return i, edit.SubtreeEnd(lines, i), item.Level
 		}
 	}
 	return -1, -1, 0
@@ -122,7 +134,7 @@ func findOLPInsertionPoint(lines []string, olp []string) (int, int, int) {
 	for _, targetTitle := range olp {
 		limit := len(lines)
 		if scopeStart != -1 {
-			limit = findEndOfSubtree(lines, scopeStart, scopeLevel)
+			limit = edit.SubtreeEnd(lines, scopeStart)
 		}
 
 		foundIndex := -1
@@ -152,17 +164,7 @@ func findOLPInsertionPoint(lines []string, olp []string) (int, int, int) {
 		}
 	}
 
-	return scopeStart, findEndOfSubtree(lines, scopeStart, scopeLevel), scopeLevel
-}
-
-func findEndOfSubtree(lines []string, startIndex int, level int) int {
-	for i := startIndex + 1; i < len(lines); i++ {
-		item := parser.ParseHeadline(lines[i])
-		if item != nil && item.Level <= level {
-			return i
-		}
-	}
-	return len(lines)
+	return scopeStart, edit.SubtreeEnd(lines, scopeStart), scopeLevel
 }
 
 func findEndOfImmediateBody(lines []string, startIndex int) int {
@@ -174,7 +176,9 @@ func findEndOfImmediateBody(lines []string, startIndex int) int {
 	return len(lines)
 }
 
-func adjustEntryLevel(entry string, targetLevel int) string {
+// AdjustEntryLevel shifts every headline of entry so that its first headline
+// ends up at targetLevel, keeping the relative levels of its children.
+func AdjustEntryLevel(entry string, targetLevel int) string {
 	lines := strings.Split(entry, "\n")
 	if len(lines) == 0 {
 		return entry
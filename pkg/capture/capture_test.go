@@ -179,7 +179,7 @@ func TestAdjustEntryLevel(t *testing.T) {
 	// Entry level 1. Shift +1.
 	// Result "** Item\n  Body"
 
-	res := adjustEntryLevel(entry, 2)
+	res := AdjustEntryLevel(entry, 2)
 	expected := "** Item\n  Body"
 	if res != expected {
 		t.Errorf("Expected %q, got %q", expected, res)
@@ -189,7 +189,7 @@ func TestAdjustEntryLevel(t *testing.T) {
 func TestAdjustEntryLevel_Multiline(t *testing.T) {
 	entry := "* Item\n** SubItem\n  Body"
 	// Shift +1
-	res := adjustEntryLevel(entry, 2)
+	res := AdjustEntryLevel(entry, 2)
 	expected := "** Item\n*** SubItem\n  Body"
 	if res != expected {
 		t.Errorf("Expected %q, got %q", expected, res)
@@ -0,0 +1,92 @@
+package capture
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// datetreeLevel is a headline of a date tree. Siblings are told apart, and
+// kept in order, by the first word of their title, which matches pattern.
+type datetreeLevel struct {
+	key     string
+	title   string
+	pattern *regexp.Regexp
+}
+
+var (
+	yearKeyRegex  = regexp.MustCompile(`^\d{4}$`)
+	monthKeyRegex = regexp.MustCompile(`^\d{4}-\d{2}$`)
+	weekKeyRegex  = regexp.MustCompile(`^\d{4}-W\d{2}$`)
+	dayKeyRegex   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// datetreePath returns the headlines leading to day in a date tree: the
+// year, month and day like org-datetree-find-date-create, or with week the
+// ISO year, week and day like org-datetree-find-iso-week-create.
+func datetreePath(day time.Time, week bool) []datetreeLevel {
+	dayLevel := datetreeLevel{key: day.Format("2006-01-02"), title: day.Format("2006-01-02 Monday"), pattern: dayKeyRegex}
+	if week {
+		year, w := day.ISOWeek()
+		return []datetreeLevel{
+			{key: fmt.Sprint(year), title: fmt.Sprint(year), pattern: yearKeyRegex},
+			{key: fmt.Sprintf("%d-W%02d", year, w), title: fmt.Sprintf("%d-W%02d", year, w), pattern: weekKeyRegex},
+			dayLevel,
+		}
+	}
+	return []datetreeLevel{
+		{key: day.Format("2006"), title: day.Format("2006"), pattern: yearKeyRegex},
+		{key: day.Format("2006-01"), title: day.Format("2006-01 January"), pattern: monthKeyRegex},
+		dayLevel,
+	}
+}
+
+// findDatetreeInsertionPoint walks down path like findOLPInsertionPoint,
+// creating the headlines that are missing, and returns the updated lines
+// with the index of the last headline of path.
+func findDatetreeInsertionPoint(lines []string, path []datetreeLevel) ([]string, int) {
+	scopeStart := -1 // Virtual root
+	scopeLevel := 0
+
+	for _, level := range path {
+		startScan, limit := 0, len(lines)
+		if scopeStart != -1 {
+			startScan, limit = scopeStart+1, edit.SubtreeEnd(lines, scopeStart)
+		}
+
+		foundIndex := -1
+		insertAt := limit
+		for i := startScan; i < limit; i++ {
+			item := parser.ParseHeadline(lines[i])
+			if item == nil || item.Level != scopeLevel+1 {
+				continue
+			}
+			key := strings.SplitN(item.Title, " ", 2)[0]
+			if !level.pattern.MatchString(key) {
+				continue
+			}
+			if key == level.key {
+				foundIndex = i
+				break
+			}
+			if key > level.key {
+				insertAt = i
+				break
+			}
+		}
+
+		if foundIndex == -1 {
+			headline := strings.Repeat("*", scopeLevel+1) + " " + level.title
+			lines = append(lines[:insertAt], append([]string{headline}, lines[insertAt:]...)...)
+			foundIndex = insertAt
+		}
+		scopeStart = foundIndex
+		scopeLevel++
+	}
+
+	return lines, scopeStart
+}
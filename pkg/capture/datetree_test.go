@@ -0,0 +1,65 @@
+package capture
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplate_InsertDatetree(t *testing.T) {
+	day := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		content string
+		week    bool
+		want    string
+	}{
+		{
+			name:    "Empty file",
+			content: "",
+			want:    "* 2026\n** 2026-01 January\n*** 2026-01-05 Monday\n**** Entry\n",
+		},
+		{
+			name:    "Existing day",
+			content: "* 2026\n** 2026-01 January\n*** 2026-01-05 Monday\n**** Earlier\n",
+			want:    "* 2026\n** 2026-01 January\n*** 2026-01-05 Monday\n**** Earlier\n**** Entry\n",
+		},
+		{
+			name:    "Sorted position",
+			content: "#+TITLE: Journal\n* 2025\n** 2025-12 December\n* 2026\n** 2026-01 January\n*** 2026-01-02 Friday\n*** 2026-01-07 Wednesday\n** 2026-02 February\n* 2027\n",
+			want:    "#+TITLE: Journal\n* 2025\n** 2025-12 December\n* 2026\n** 2026-01 January\n*** 2026-01-02 Friday\n*** 2026-01-05 Monday\n**** Entry\n*** 2026-01-07 Wednesday\n** 2026-02 February\n* 2027\n",
+		},
+		{
+			name:    "New year between others",
+			content: "* Notes\n* 2025\n* 2027\n",
+			want:    "* Notes\n* 2025\n* 2026\n** 2026-01 January\n*** 2026-01-05 Monday\n**** Entry\n* 2027\n",
+		},
+		{
+			name:    "Week tree",
+			content: "* 2026\n** 2026-W01\n*** 2026-01-01 Thursday\n",
+			week:    true,
+			want:    "* 2026\n** 2026-W01\n*** 2026-01-01 Thursday\n** 2026-W02\n*** 2026-01-05 Monday\n**** Entry\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := Template{Target: TargetDatetree}
+			if tt.week {
+				tmpl.Target = TargetWeektree
+			}
+			got, err := tmpl.Insert(tt.content, "* Entry\n", day)
+			if err != nil || got != tt.want {
+				t.Errorf("Insert() =\n%s, %v\nwant\n%s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplate_InsertWeektreeISOYear(t *testing.T) {
+	// 2027-01-01 belongs to the last ISO week of 2026.
+	tmpl := Template{Target: TargetWeektree}
+	got, err := tmpl.Insert("", "Text\n", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	want := "* 2026\n** 2026-W53\n*** 2027-01-01 Friday\nText\n"
+	if err != nil || got != want {
+		t.Errorf("Insert() = %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,399 @@
+package capture
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/garaemon/org-agenda-cli/pkg/dateinput"
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// backRef is a %\N escape waiting for the answer to the Nth prompt, to be
+// inserted at pos of the output.
+type backRef struct {
+	pos int
+	n   int
+}
+
+// expand replaces the escapes of format for in in a single pass, so that
+// text inserted for an escape, such as the content or an answer, is never
+// expanded again. "\%" stands for a literal "%". Prompts are only asked when
+// in has a Prompter and are left alone otherwise. The properties set by
+// %^{PROP}p prompts are returned in the order they were asked for, along
+// with warnings about escapes that expanded to nothing unexpectedly.
+func expand(format string, in Input) (string, [][2]string, []string, error) {
+	var (
+		out      strings.Builder
+		answers  []string
+		props    [][2]string
+		refs     []backRef
+		warnings []string
+	)
+	now := in.Time
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c == '\\' && i+1 < len(format) && format[i+1] == '%' {
+			out.WriteByte('%')
+			i++
+			continue
+		}
+		if c != '%' || i+1 == len(format) {
+			out.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch next := format[i]; next {
+		case 'c':
+			out.WriteString(indentLike(in.Content, currentColumn(out.String())))
+		case 'i':
+			out.WriteString(indentLike(in.Initial, currentColumn(out.String())))
+		case 't', 'T':
+			out.WriteString(parser.FormatTimestamp(now, true, true))
+		case 'u':
+			out.WriteString(parser.FormatTimestamp(now, false, false))
+		case 'U':
+			out.WriteString(parser.FormatTimestamp(now, false, true))
+		case 'a':
+			if in.Link == "" && len(warnings) == 0 {
+				// %a used to be the abbreviated weekday.
+				warnings = append(warnings, "%a inserts the link of the capture, but none was given; use %<%a> for the abbreviated weekday")
+			}
+			out.WriteString(bracketLink(in.Link))
+		case 'l':
+			out.WriteString(in.Link)
+		case 'L':
+			cwd, err := os.Getwd()
+			if err != nil {
+				cwd = "unknown"
+			}
+			fmt.Fprintf(&out, "[[file:%s][%s]]", cwd, cwd)
+		case 'f':
+			if in.File != "" {
+				out.WriteString(filepath.Base(in.File))
+			}
+		case 'F':
+			out.WriteString(in.File)
+		case 'k':
+			out.WriteString(in.Clocked)
+		case 'K':
+			out.WriteString(bracketLink(in.ClockedLink))
+		case 'Y', 'y', 'm', 'd', 'H', 'M', 'S', 'A':
+			out.WriteString(now.Format(legacyLayouts[next]))
+
+		case '<':
+			end := strings.IndexByte(format[i:], '>')
+			if end == -1 {
+				return "", nil, nil, fmt.Errorf("unterminated %%< in %q", format[i-1:])
+			}
+			out.WriteString(strftime(format[i+1:i+end], now))
+			i += end
+
+		case '(':
+			end := closingParen(format, i)
+			if end == -1 {
+				return "", nil, nil, fmt.Errorf("unterminated %%( in %q", format[i-1:])
+			}
+			if !in.Shell {
+				return "", nil, nil, fmt.Errorf("shell escapes such as %%(%s) are disabled", format[i+1:end])
+			}
+			output, err := runShell(format[i+1 : end])
+			if err != nil {
+				return "", nil, nil, err
+			}
+			out.WriteString(output)
+			i = end
+
+		case '\\':
+			if i+1 < len(format) && format[i+1] >= '1' && format[i+1] <= '9' {
+				refs = append(refs, backRef{pos: out.Len(), n: int(format[i+1] - '0')})
+				i++
+			} else {
+				out.WriteString("%\\")
+			}
+
+		case '^':
+			if in.Prompter == nil {
+				out.WriteString("%^")
+				continue
+			}
+			end, answer, err := expandPrompt(format, i-1, &out, &props, in)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			if end == -1 {
+				// Not a prompt after all.
+				out.WriteString("%^")
+				continue
+			}
+			if format[i+1] == '{' {
+				answers = append(answers, answer)
+			}
+			i = end - 1
+
+		default:
+			out.WriteByte('%')
+			out.WriteByte(next)
+		}
+	}
+
+	result := out.String()
+	for j := len(refs) - 1; j >= 0; j-- {
+		r := refs[j]
+		if r.n > len(answers) {
+			return "", nil, nil, fmt.Errorf("%%\\%d refers to a missing prompt", r.n)
+		}
+		result = result[:r.pos] + answers[r.n-1] + result[r.pos:]
+	}
+	return result, props, warnings, nil
+}
+
+// legacyLayouts are the single-letter date escapes.
+var legacyLayouts = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'H': "15",
+	'M': "04",
+	'S': "05",
+	'A': "Monday",
+}
+
+// expandPrompt asks the prompt starting with the "%^" at start of format,
+// writes its expansion to out and returns the position after it along with
+// the answer. The position is -1 when format does not hold a prompt there:
+//
+//	%^{Prompt|default|choice2}  text, with a default and completion choices
+//	%^g, %^G                    tags, inserted as ":tag1:tag2:"
+//	%^t, %^T, %^u, %^U          a date, inserted as an active or inactive
+//	                            timestamp, with the time of day for T and U;
+//	                            %^{Prompt}t names the prompt
+//	%^{PROP}p                   the value of property PROP, which is added
+//	                            to props instead of being inserted
+//
+// Dates are read like dateinput.Parse relative to the time of the capture.
+func expandPrompt(format string, start int, out *strings.Builder, props *[][2]string, in Input) (int, string, error) {
+	j := start + 2
+	if j >= len(format) {
+		return -1, "", nil
+	}
+	var name string
+	var choices []string
+	braced := format[j] == '{'
+	if braced {
+		end := strings.IndexByte(format[j:], '}')
+		if end == -1 {
+			return 0, "", fmt.Errorf("unterminated prompt in %q", format[start:])
+		}
+		parts := strings.Split(format[j+1:j+end], "|")
+		name, choices = parts[0], parts[1:]
+		j += end + 1
+	}
+
+	kind := ""
+	if j < len(format) {
+		switch format[j] {
+		case 'p':
+			if braced {
+				kind = PromptProperty
+			}
+		case 't', 'T', 'u', 'U':
+			kind = PromptDate
+		case 'g', 'G':
+			if !braced {
+				kind = PromptTags
+			}
+		}
+	}
+	switch {
+	case kind == "" && !braced:
+		return -1, "", nil
+	case kind == "":
+		kind = PromptText
+	default:
+		j++
+	}
+	if name == "" {
+		switch kind {
+		case PromptDate:
+			name = "Date"
+		case PromptTags:
+			name = "Tags"
+		}
+	}
+
+	answer, err := in.Prompter.Ask(Prompt{Kind: kind, Name: name, Choices: choices})
+	if err != nil {
+		return 0, "", err
+	}
+	switch kind {
+	case PromptText:
+		out.WriteString(answer)
+	case PromptTags:
+		tags := splitTags(answer)
+		for _, tag := range tags {
+			if !edit.ValidTag(tag) {
+				return 0, "", fmt.Errorf("invalid tag %q", tag)
+			}
+		}
+		if len(tags) > 0 {
+			out.WriteString(":" + strings.Join(tags, ":") + ":")
+		}
+	case PromptDate:
+		d, err := dateinput.Parse(answer, in.Time)
+		if err != nil {
+			return 0, "", fmt.Errorf("%s: %w", name, err)
+		}
+		c := format[j-1]
+		withTime := (c == 'T' || c == 'U') && d.HasTime
+		answer = parser.FormatTimestamp(d.Time, c == 't' || c == 'T', withTime)
+		out.WriteString(answer)
+	case PromptProperty:
+		*props = append(*props, [2]string{name, strings.TrimSpace(answer)})
+	}
+	return j, answer, nil
+}
+
+// currentColumn returns the width of the last line of s.
+func currentColumn(s string) int {
+	return utf8.RuneCountInString(s[strings.LastIndexByte(s, '\n')+1:])
+}
+
+// indentLike indents every non-empty line of text but the first by column
+// spaces, so that the text lines up with where it starts.
+func indentLike(text string, column int) string {
+	text = strings.TrimRight(text, "\n")
+	if column == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	indent := strings.Repeat(" ", column)
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// bracketLink returns link as an Org link, "" when there is none.
+func bracketLink(link string) string {
+	if link == "" || strings.HasPrefix(link, "[[") {
+		return link
+	}
+	return "[[" + link + "]]"
+}
+
+// closingParen returns the index of the parenthesis closing the one at open,
+// or -1.
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// runShell runs command with sh and returns its output without the final
+// newline.
+func runShell(command string) (string, error) {
+	output, err := exec.Command("sh", "-c", command).Output()
+	if err != nil {
+		return "", fmt.Errorf("%%(%s) failed: %w", command, err)
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// strftime formats t like the C function, for the conversions Org templates
+// commonly use. Unknown conversions are kept as they are.
+func strftime(layout string, t time.Time) string {
+	var out strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' || i+1 == len(layout) {
+			out.WriteByte(layout[i])
+			continue
+		}
+		i++
+		switch layout[i] {
+		case 'Y':
+			out.WriteString(t.Format("2006"))
+		case 'y':
+			out.WriteString(t.Format("06"))
+		case 'm':
+			out.WriteString(t.Format("01"))
+		case 'd':
+			out.WriteString(t.Format("02"))
+		case 'e':
+			fmt.Fprintf(&out, "%2d", t.Day())
+		case 'H':
+			out.WriteString(t.Format("15"))
+		case 'I':
+			out.WriteString(t.Format("03"))
+		case 'M':
+			out.WriteString(t.Format("04"))
+		case 'S':
+			out.WriteString(t.Format("05"))
+		case 'p':
+			out.WriteString(t.Format("PM"))
+		case 'A':
+			out.WriteString(t.Format("Monday"))
+		case 'a':
+			out.WriteString(t.Format("Mon"))
+		case 'B':
+			out.WriteString(t.Format("January"))
+		case 'b', 'h':
+			out.WriteString(t.Format("Jan"))
+		case 'j':
+			fmt.Fprintf(&out, "%03d", t.YearDay())
+		case 'u':
+			wd := int(t.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			out.WriteString(strconv.Itoa(wd))
+		case 'w':
+			out.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'V':
+			_, w := t.ISOWeek()
+			fmt.Fprintf(&out, "%02d", w)
+		case 'G':
+			y, _ := t.ISOWeek()
+			out.WriteString(strconv.Itoa(y))
+		case 'F':
+			out.WriteString(t.Format("2006-01-02"))
+		case 'R':
+			out.WriteString(t.Format("15:04"))
+		case 'T':
+			out.WriteString(t.Format("15:04:05"))
+		case 'Z':
+			out.WriteString(t.Format("MST"))
+		case 'z':
+			out.WriteString(t.Format("-0700"))
+		case 's':
+			out.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case '%':
+			out.WriteByte('%')
+		default:
+			out.WriteByte('%')
+			out.WriteByte(layout[i])
+		}
+	}
+	return out.String()
+}
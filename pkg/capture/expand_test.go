@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	now := time.Date(2026, 1, 5, 9, 7, 3, 0, time.UTC)
+	in := Input{
+		Content:     "Fix %t in %c",
+		Initial:     "line one\nline two\n",
+		Time:        now,
+		Link:        "file:/src/main.go::42",
+		Clocked:     "Review",
+		ClockedLink: "id:abc",
+		File:        "/org/inbox.org",
+	}
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{name: "Content is not expanded again", format: "* %c", want: "* Fix %t in %c"},
+		{name: "Timestamps", format: "%t %T %u %U", want: "<2026-01-05 Mon 09:07> <2026-01-05 Mon 09:07> [2026-01-05 Mon] [2026-01-05 Mon 09:07]"},
+		{name: "Strftime", format: "%<%Y-%m-%d %a %H:%M:%S %%>", want: "2026-01-05 Mon 09:07:03 %"},
+		{name: "Legacy date escapes", format: "%Y/%y/%m/%d %H:%M:%S %A", want: "2026/26/01/05 09:07:03 Monday"},
+		{name: "Initial content", format: "* Log\n  - %i", want: "* Log\n  - line one\n    line two"},
+		{name: "Links", format: "%a %l", want: "[[file:/src/main.go::42]] file:/src/main.go::42"},
+		{name: "Files", format: "%f %F", want: "inbox.org /org/inbox.org"},
+		{name: "Clock", format: "%k %K", want: "Review [[id:abc]]"},
+		{name: "Literal percent", format: `\%c 100% %z`, want: "%c 100% %z"},
+		{name: "Shell disabled", format: "%(echo hi)", wantErr: true},
+		{name: "Unterminated strftime", format: "%<%Y", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, _, err := expand(tt.format, in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpand_Shell(t *testing.T) {
+	in := Input{Content: "x", Shell: true}
+	got, _, _, err := expand("rev %(echo abc | tr a-c A-C) (%c)", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "rev ABC (x)" {
+		t.Errorf("expand() = %q", got)
+	}
+	if _, _, _, err := expand("%(exit 3)", in); err == nil {
+		t.Error("Expected an error for a failing command")
+	}
+}
+
+func TestExpand_MissingLinkWarning(t *testing.T) {
+	in := Input{Content: "x", Time: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)}
+	got, _, warnings, err := expand("%a %<%a> %a", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != " Mon " {
+		t.Errorf("expand() = %q", got)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "%<%a>") {
+		t.Errorf("Unexpected warnings: %q", warnings)
+	}
+
+	in.Link = "https://example.com"
+	if _, _, warnings, err := expand("%a", in); err != nil || len(warnings) != 0 {
+		t.Errorf("Unexpected warnings with a link: %q, %v", warnings, err)
+	}
+}
@@ -0,0 +1,31 @@
+package capture
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxInputSize is the largest input ReadInput accepts, in bytes.
+const MaxInputSize = 1 << 20
+
+// ReadInput reads text piped to a capture, such as the output of a command,
+// for use as the content or as %i. Line endings are normalized and trailing
+// newlines dropped. Input larger than limit bytes, or that does not look
+// like text, is refused rather than written to an Org file.
+func ReadInput(r io.Reader, limit int64) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the input: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return "", fmt.Errorf("the input is larger than %d bytes", limit)
+	}
+	if bytes.IndexByte(data, 0) != -1 || !utf8.Valid(data) {
+		return "", fmt.Errorf("the input is not text")
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.TrimRight(text, "\n"), nil
+}
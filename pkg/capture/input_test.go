@@ -0,0 +1,41 @@
+package capture
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		limit   int64
+		want    string
+		wantErr string
+	}{
+		{"Text", "commit abc\r\n\n    Fix it\n\n", 100, "commit abc\n\n    Fix it", ""},
+		{"Empty", "", 100, "", ""},
+		{"At limit", "12345", 5, "12345", ""},
+		{"Too large", "123456", 5, "", "the input is larger than 5 bytes"},
+		{"NUL byte", "ELF\x00\x01", 100, "", "the input is not text"},
+		{"Invalid UTF-8", "caf\xe9", 100, "", "the input is not text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadInput(strings.NewReader(tt.input), tt.limit)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ReadInput() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadInput() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ReadInput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
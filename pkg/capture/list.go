@@ -0,0 +1,220 @@
+package capture
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+)
+
+var (
+	bulletRegex     = regexp.MustCompile(`^(\s*)([-+]|(\d+)([.)]))(\s+)`)
+	tableRowRegex   = regexp.MustCompile(`^\s*\|`)
+	tableRuleRegex  = regexp.MustCompile(`^\s*\|-`)
+	blockBeginRegex = regexp.MustCompile(`(?i)^\s*#\+begin_`)
+	blockEndRegex   = regexp.MustCompile(`(?i)^\s*#\+end_`)
+	drawerRegex     = regexp.MustCompile(`^\s*:[\w-]+:\s*$`)
+)
+
+// bodyRange returns the lines of the body of the headline at idx, or of the
+// text before the first headline when idx is -1, without the planning line
+// and property drawer.
+func bodyRange(lines []string, idx int) (int, int) {
+	if idx == -1 {
+		for i, line := range lines {
+			if edit.IsHeadline(line) {
+				return 0, i
+			}
+		}
+		return 0, len(lines)
+	}
+	start := idx + 1
+	if p := edit.PlanningLine(lines, idx); p != -1 {
+		start = p + 1
+	}
+	if _, end := edit.PropertyDrawer(lines, idx); end != -1 {
+		start = end + 1
+	}
+	return start, edit.EntryEnd(lines, idx)
+}
+
+// scanBody calls match for every line of lines[start:end] outside blocks and
+// drawers, until it returns true, and returns the index of that line or -1.
+func scanBody(lines []string, start, end int, match func(string) bool) int {
+	inBlock, inDrawer := false, false
+	for i := start; i < end; i++ {
+		line := lines[i]
+		switch {
+		case inBlock:
+			inBlock = !blockEndRegex.MatchString(line)
+		case inDrawer:
+			inDrawer = !strings.EqualFold(strings.TrimSpace(line), ":END:")
+		case blockBeginRegex.MatchString(line):
+			inBlock = true
+		case drawerRegex.MatchString(line):
+			inDrawer = true
+		case match(line):
+			return i
+		}
+	}
+	return -1
+}
+
+// bodyEnd returns where text appended to lines[start:end] goes: after its
+// last non-blank line.
+func bodyEnd(lines []string, start, end int) int {
+	for end > start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+// indentOf returns the leading whitespace of line.
+func indentOf(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// reindent moves the lines of text so that the first one starts with indent,
+// keeping the indentation of the others relative to it.
+func reindent(text []string, indent string) []string {
+	current := indentOf(text[0])
+	out := make([]string, len(text))
+	for i, line := range text {
+		switch {
+		case strings.TrimSpace(line) == "":
+			out[i] = ""
+		case strings.HasPrefix(line, current):
+			out[i] = indent + line[len(current):]
+		default:
+			out[i] = indent + strings.TrimLeft(line, " \t")
+		}
+	}
+	return out
+}
+
+// entryLines returns the lines of an item or table entry, without the blank
+// lines around it, which would end the list.
+func entryLines(entry string) []string {
+	return strings.Split(strings.Trim(entry, "\n"), "\n")
+}
+
+// insertListItem adds the item entry to the first plain list in the body of
+// the headline at idx, or of the text before the first headline when idx is
+// -1: at its end, or before its first item with prepend. The item takes the
+// indentation and bullet of the list, counting on for ordered lists. A new
+// list is started at the end of the body, or at its start with prepend,
+// indented like the text of the body.
+func insertListItem(lines []string, idx int, entry string, prepend bool) string {
+	item := entryLines(entry)
+	start, end := bodyRange(lines, idx)
+	first := scanBody(lines, start, end, bulletRegex.MatchString)
+
+	if first == -1 {
+		return startBlock(lines, start, end, item, prepend)
+	}
+
+	indent := indentOf(lines[first])
+	last, at := first, listEnd(lines, first, end)
+	for i := first; i < at; i++ {
+		if bulletRegex.MatchString(lines[i]) && indentOf(lines[i]) == indent {
+			last = i
+		}
+	}
+	bullet := bulletRegex.FindStringSubmatch(lines[last])
+	if prepend {
+		at, bullet = first, bulletRegex.FindStringSubmatch(lines[first])
+	}
+	item = reindent(item, indent)
+	item[0] = withBullet(item[0], bullet, prepend)
+	return edit.JoinLines(edit.InsertLines(lines, at, item...))
+}
+
+// listEnd returns the index after the plain list whose first item is at
+// first: it goes on while lines are items or indented deeper than the list,
+// and ends at a line that is not, or at two blank lines.
+func listEnd(lines []string, first, end int) int {
+	indent := len(indentOf(lines[first]))
+	last := first
+	for i := first + 1; i < end; i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			if i+1 < end && strings.TrimSpace(lines[i+1]) == "" {
+				break
+			}
+			continue
+		}
+		ind := len(indentOf(line))
+		if ind < indent || (ind == indent && !bulletRegex.MatchString(line)) {
+			break
+		}
+		last = i
+	}
+	return last + 1
+}
+
+// withBullet gives the item line the bullet of the list item whose bullet
+// submatches are list: the same bullet for unordered lists, and the next
+// number, or the same one before the first item, for ordered ones.
+func withBullet(line string, list []string, prepend bool) string {
+	own := bulletRegex.FindStringSubmatch(line)
+	if own == nil {
+		return line
+	}
+	bullet := list[2]
+	if list[3] != "" {
+		n, _ := strconv.Atoi(list[3])
+		if !prepend {
+			n++
+		}
+		bullet = strconv.Itoa(n) + list[4]
+	}
+	return own[1] + bullet + " " + line[len(own[0]):]
+}
+
+// insertTableLine adds the table row entry to the first table in the body
+// of the headline at idx, or of the text before the first headline when idx
+// is -1: after its last row, or with prepend after the rule below its header
+// or before its first row when there is no rule. The row is indented like
+// the table. A new table is started like a new list.
+func insertTableLine(lines []string, idx int, entry string, prepend bool) string {
+	row := entryLines(entry)
+	start, end := bodyRange(lines, idx)
+	first := scanBody(lines, start, end, tableRowRegex.MatchString)
+
+	if first == -1 {
+		return startBlock(lines, start, end, row, prepend)
+	}
+
+	last := first
+	for last+1 < end && tableRowRegex.MatchString(lines[last+1]) {
+		last++
+	}
+	at := last + 1
+	if prepend {
+		at = first
+		for i := first; i <= last; i++ {
+			if tableRuleRegex.MatchString(lines[i]) {
+				at = i + 1
+				break
+			}
+		}
+	}
+	return edit.JoinLines(edit.InsertLines(lines, at, reindent(row, indentOf(lines[first]))...))
+}
+
+// startBlock inserts text, the first item of a list or row of a table, at
+// the end of lines[start:end], or at its start with prepend, indented like
+// the text there.
+func startBlock(lines []string, start, end int, text []string, prepend bool) string {
+	indent := ""
+	nonBlank := func(line string) bool { return strings.TrimSpace(line) != "" }
+	if i := scanBody(lines, start, end, nonBlank); i != -1 {
+		indent = indentOf(lines[i])
+	}
+	at := bodyEnd(lines, start, end)
+	if prepend {
+		at = start
+	}
+	return edit.JoinLines(edit.InsertLines(lines, at, reindent(text, indent)...))
+}
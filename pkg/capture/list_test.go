@@ -0,0 +1,134 @@
+package capture
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplate_InsertItem(t *testing.T) {
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		tmpl    Template
+		content string
+		entry   string
+		want    string
+	}{
+		{
+			name:    "Append to list",
+			tmpl:    Template{Type: TypeCheckItem, Target: TargetHeadline, Headline: "Shopping"},
+			content: "* Shopping\nBuy on Friday.\n- [X] Milk\n- [ ] Bread\n  whole grain\n\nThanks.\n* Books\n",
+			entry:   "- [ ] Eggs\n",
+			want:    "* Shopping\nBuy on Friday.\n- [X] Milk\n- [ ] Bread\n  whole grain\n- [ ] Eggs\n\nThanks.\n* Books\n",
+		},
+		{
+			name:    "Indentation and bullet of the list",
+			tmpl:    Template{Type: TypeItem, Target: TargetHeadline, Headline: "Reading"},
+			content: "* Reading\n  + Dune\n    - reread\n  + Emma\n",
+			entry:   "- Ulysses\n  long\n",
+			want:    "* Reading\n  + Dune\n    - reread\n  + Emma\n  + Ulysses\n    long\n",
+		},
+		{
+			name:    "Ordered list",
+			tmpl:    Template{Type: TypeItem, Target: TargetHeadline, Headline: "Steps"},
+			content: "* Steps\n1) Plan\n2) Build\n",
+			entry:   "- Ship\n",
+			want:    "* Steps\n1) Plan\n2) Build\n3) Ship\n",
+		},
+		{
+			name:    "Prepend",
+			tmpl:    Template{Type: TypeItem, Target: TargetHeadline, Headline: "Steps", Prepend: true},
+			content: "* Steps\n:PROPERTIES:\n:ID: steps\n:END:\n- Plan\n- Build\n",
+			entry:   "- Think\n",
+			want:    "* Steps\n:PROPERTIES:\n:ID: steps\n:END:\n- Think\n- Plan\n- Build\n",
+		},
+		{
+			name:    "New list after the body",
+			tmpl:    Template{Type: TypeCheckItem, Target: TargetHeadline, Headline: "Shopping", EmptyLines: 1},
+			content: "* Shopping\n  SCHEDULED: <2026-01-09 Fri>\n  For the weekend.\n\n* Books\n",
+			entry:   "\n- [ ] Eggs\n\n",
+			want:    "* Shopping\n  SCHEDULED: <2026-01-09 Fri>\n  For the weekend.\n  - [ ] Eggs\n\n* Books\n",
+		},
+		{
+			name:    "Lists in blocks are skipped",
+			tmpl:    Template{Type: TypeItem, Target: TargetHeadline, Headline: "Notes"},
+			content: "* Notes\n#+begin_src markdown\n- not a list\n#+end_src\n",
+			entry:   "- Item\n",
+			want:    "* Notes\n#+begin_src markdown\n- not a list\n#+end_src\n- Item\n",
+		},
+		{
+			name:    "Top of the file",
+			tmpl:    Template{Type: TypeItem},
+			content: "#+TITLE: Inbox\n- One\n* Tasks\n",
+			entry:   "- Two\n",
+			want:    "#+TITLE: Inbox\n- One\n- Two\n* Tasks\n",
+		},
+		{
+			name:    "Date tree",
+			tmpl:    Template{Type: TypeItem, Target: TargetDatetree},
+			content: "",
+			entry:   "- Ran 5k\n",
+			want:    "* 2026\n** 2026-01 January\n*** 2026-01-05 Monday\n- Ran 5k\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tmpl.Insert(tt.content, tt.entry, now)
+			if err != nil {
+				t.Fatalf("Insert() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Insert() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplate_InsertTableLine(t *testing.T) {
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	table := "* Books\nRead in 2026:\n| Title | Author |\n|-------+--------|\n| Dune  | Herbert |\n\nMore later.\n"
+	tests := []struct {
+		name    string
+		tmpl    Template
+		content string
+		want    string
+	}{
+		{
+			name:    "Append",
+			tmpl:    Template{Type: TypeTableLine, Target: TargetHeadline, Headline: "Books"},
+			content: table,
+			want:    "* Books\nRead in 2026:\n| Title | Author |\n|-------+--------|\n| Dune  | Herbert |\n| Emma | Austen |\n\nMore later.\n",
+		},
+		{
+			name:    "Prepend after the header",
+			tmpl:    Template{Type: TypeTableLine, Target: TargetHeadline, Headline: "Books", Prepend: true},
+			content: table,
+			want:    "* Books\nRead in 2026:\n| Title | Author |\n|-------+--------|\n| Emma | Austen |\n| Dune  | Herbert |\n\nMore later.\n",
+		},
+		{
+			name:    "Indented table",
+			tmpl:    Template{Type: TypeTableLine, Target: TargetHeadline, Headline: "Books"},
+			content: "* Books\n  | Dune | Herbert |\n",
+			want:    "* Books\n  | Dune | Herbert |\n  | Emma | Austen |\n",
+		},
+		{
+			name:    "New table",
+			tmpl:    Template{Type: TypeTableLine, Target: TargetHeadline, Headline: "Books"},
+			content: "* Books\n* Films\n",
+			want:    "* Books\n| Emma | Austen |\n* Films\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tmpl.Insert(tt.content, "| Emma | Austen |\n", now)
+			if err != nil {
+				t.Fatalf("Insert() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Insert() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package capture
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+)
+
+// Prompt kinds.
+const (
+	PromptText     = "text"
+	PromptTags     = "tags"
+	PromptDate     = "date"
+	PromptProperty = "property"
+)
+
+// Prompt is a question asked while expanding a template. Name is the text
+// of the prompt, "Tags" and "Date" unless the template names them, or the
+// property for property prompts. Choices are the values offered for
+// completion; the first one is the default.
+type Prompt struct {
+	Kind    string
+	Name    string
+	Choices []string
+}
+
+// Prompter answers the prompts of a template.
+type Prompter interface {
+	Ask(p Prompt) (string, error)
+}
+
+// Answers is a Prompter answering from values given in advance, keyed by
+// prompt name. Prompts without a value get their default.
+type Answers map[string]string
+
+func (a Answers) Ask(p Prompt) (string, error) {
+	if value, ok := a[p.Name]; ok {
+		return value, nil
+	}
+	if len(p.Choices) > 0 {
+		return p.Choices[0], nil
+	}
+	return "", fmt.Errorf("no value given for the prompt %q", p.Name)
+}
+
+// splitTags reads tags typed as "a b", "a,b" or ":a:b:".
+func splitTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ':' || r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// setEntryProperties sets props in the property drawer of the first
+// headline of entry.
+func setEntryProperties(entry string, props [][2]string) (string, error) {
+	lines := strings.Split(entry, "\n")
+	idx := -1
+	for i, line := range lines {
+		if edit.IsHeadline(line) {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return "", fmt.Errorf("property prompts need an entry starting with a headline")
+	}
+	for _, prop := range props {
+		lines = edit.SetProperty(lines, idx, prop[0], prop[1])
+	}
+	return strings.Join(lines, "\n"), nil
+}
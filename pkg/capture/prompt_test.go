@@ -0,0 +1,69 @@
+package capture
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpand_Prompts(t *testing.T) {
+	now := time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)
+	answers := Answers{
+		"Project":  "website",
+		"Tags":     "work, urgent",
+		"Date":     "fri",
+		"Meeting":  "2026-01-07 14:00",
+		"OWNER":    " bob ",
+		"Priority": "",
+	}
+	tests := []struct {
+		name      string
+		format    string
+		want      string
+		wantProps [][2]string
+		wantErr   bool
+	}{
+		{name: "Text", format: "* %^{Project} %c", want: "* website Call"},
+		{name: "Default", format: "* %^{Status|open|closed}", want: "* open"},
+		{name: "Empty answer", format: "[%^{Priority|B}]", want: "[]"},
+		{name: "Tags", format: "* Entry %^g", want: "* Entry :work:urgent:"},
+		{name: "Active date", format: "%^t", want: "<2026-01-09 Fri>"},
+		{name: "Named date with time", format: "%^{Meeting}T", want: "<2026-01-07 Wed 14:00>"},
+		{name: "Inactive date", format: "%^u", want: "[2026-01-09 Fri]"},
+		{name: "Property", format: "* Task%^{OWNER}p", want: "* Task", wantProps: [][2]string{{"OWNER", "bob"}}},
+		{name: "Back-reference", format: "* %^{Project}\n  About %\\1", want: "* website\n  About website"},
+		{name: "Not a prompt", format: "%^x 100%", want: "%^x 100%"},
+		{name: "Missing answer", format: "%^{Unknown}", wantErr: true},
+		{name: "Missing reference", format: "%\\2 %^{Project}", wantErr: true},
+		{name: "Unterminated", format: "%^{Project", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, props, _, err := expand(tt.format, Input{Content: "Call", Time: now, Prompter: answers})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+			if len(props) != len(tt.wantProps) || (len(props) > 0 && props[0] != tt.wantProps[0]) {
+				t.Errorf("expand() props = %v, want %v", props, tt.wantProps)
+			}
+		})
+	}
+}
+
+func TestTemplate_EntryProperties(t *testing.T) {
+	tmpl := Template{Type: TypeEntry, Format: "* TODO %c%^{OWNER}p\n  Body"}
+	got, _, err := tmpl.Entry(Input{Content: "Task", Time: time.Now(), Prompter: Answers{"OWNER": "alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "* TODO Task\n:PROPERTIES:\n:OWNER: alice\n:END:\n  Body\n"; got != want {
+		t.Errorf("Entry() = %q, want %q", got, want)
+	}
+
+	tmpl = Template{Type: TypePlain, Format: "%c%^{OWNER}p"}
+	if _, _, err := tmpl.Entry(Input{Content: "Text", Prompter: Answers{"OWNER": "alice"}}); err == nil {
+		t.Error("Expected an error for a property prompt without a headline")
+	}
+}
@@ -2,48 +2,277 @@ package capture
 
 import (
 	"fmt"
-	"os"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
 )
 
-// Format replaces placeholders in the template with actual values.
-// Supports:
-// %c: content
-// %t: timestamp <YYYY-MM-DD Mon HH:MM>
-// %L: Location link ([[file:cwd][cwd]])
-// %Y: Year (2006)
-// %y: Year (06)
-// %m: Month (01)
-// %d: Day (02)
-// %H: Hour (15)
-// %M: Minute (04)
-// %S: Second (05)
-// %A: Day of week (Monday)
-// %a: Day of week (Mon)
+// Format expands the escapes of template for content captured now. See
+// FormatAt.
 func Format(template string, content string) string {
-	now := time.Now()
-	cwd, err := os.Getwd()
+	return FormatAt(template, content, time.Now())
+}
+
+// FormatAt expands the escapes of template for content captured at now,
+// leaving prompts alone:
+//
+//	%c, %i    the content and the initial content, with further lines
+//	          indented like the escape itself
+//	%t, %T    active timestamp with the time, <2026-01-05 Mon 10:00>
+//	%u, %U    inactive timestamp, without and with the time
+//	%<...>    now formatted with strftime conversions, e.g. %<%Y-%m-%d>
+//	%a, %l    the link given with the capture, as Org link or literally
+//	%L        link to the current working directory
+//	%f, %F    name and path of the target file
+//	%k, %K    title of and link to the clocked-in task
+//	%Y %y %m %d %H %M %S %A   year, two-digit year, month, day, hour,
+//	          minute, second and weekday
+//
+// The template is returned unchanged when it cannot be expanded.
+func FormatAt(template string, content string, now time.Time) string {
+	output, _, _, err := expand(template, Input{Content: content, Time: now})
+	if err != nil {
+		return template
+	}
+	return output
+}
+
+// Template types, named after the entry types of org-capture-templates.
+const (
+	TypeEntry     = "entry"
+	TypeItem      = "item"
+	TypeCheckItem = "checkitem"
+	TypePlain     = "plain"
+	TypeTableLine = "table-line"
+)
+
+// Template targets, named after the targets of org-capture-templates.
+const (
+	TargetFile     = "file"
+	TargetHeadline = "file+headline"
+	TargetOLP      = "file+olp"
+	TargetDatetree = "file+datetree"
+	TargetWeektree = "file+weektree"
+	TargetID       = "id"
+)
+
+// defaultFormats are the formats of templates that do not set one.
+var defaultFormats = map[string]string{
+	"":            "* %t\n  %c",
+	TypeEntry:     "* %c",
+	TypeItem:      "- %c",
+	TypeCheckItem: "- [ ] %c",
+	TypePlain:     "%c",
+	TypeTableLine: "| %c |",
+}
+
+var (
+	listItemRegex  = regexp.MustCompile(`^\s*([-+]|\d+[.)])\s`)
+	checkItemRegex = regexp.MustCompile(`^\s*([-+]|\d+[.)])\s+\[[ X-]\]`)
+)
+
+// Template is a capture template, the equivalent of an entry of
+// org-capture-templates. Target decides where entries go in File.
+type Template struct {
+	// Key selects the template on the command line.
+	Key         string
+	Description string
+	// Type is one of the Type constants. An empty Type inserts text starting
+	// with a headline as an entry and anything else as plain text, which is
+	// how the single capture format behaves.
+	Type string
+	// Target is one of the Target constants: the end of File, below
+	// Headline or OLP in it, below the day of the capture in a date tree or
+	// ISO week tree, or below the headline whose :ID: or :CUSTOM_ID: is ID.
+	Target string
+	// File is the target file. For TargetID it is the file the caller found
+	// the headline in.
+	File     string
+	Headline string
+	OLP      []string
+	ID       string
+	// Format is expanded by Entry; an empty Format uses the default of Type.
+	Format string
+	// Prepend inserts at the start of the target instead of its end.
+	Prepend bool
+	// EmptyLines surrounds the entry with as many blank lines.
+	EmptyLines int
+}
+
+// Validate reports settings of t that cannot work.
+func (t Template) Validate() error {
+	name := t.Key
+	if name == "" {
+		name = "default"
+	}
+	if _, ok := defaultFormats[t.Type]; !ok {
+		return fmt.Errorf("capture template %s: unknown type %q", name, t.Type)
+	}
+	if t.File == "" && t.Target != TargetID {
+		return fmt.Errorf("capture template %s: no target file", name)
+	}
+	switch t.Target {
+	case "", TargetFile, TargetDatetree, TargetWeektree:
+	case TargetHeadline:
+		if t.Headline == "" {
+			return fmt.Errorf("capture template %s: %s needs a headline", name, t.Target)
+		}
+	case TargetOLP:
+		if len(t.OLP) == 0 {
+			return fmt.Errorf("capture template %s: %s needs an outline path", name, t.Target)
+		}
+	case TargetID:
+		if t.ID == "" {
+			return fmt.Errorf("capture template %s: %s needs an ID", name, t.Target)
+		}
+	default:
+		return fmt.Errorf("capture template %s: unknown target %q", name, t.Target)
+	}
+	if t.EmptyLines < 0 {
+		return fmt.Errorf("capture template %s: empty lines cannot be negative", name)
+	}
+	return nil
+}
+
+// Input is what a template is expanded with: the captured Content, the
+// Initial content for %i, the Time of the capture and the Prompter answering
+// the prompts of the template, which may be nil when it has none. Link is
+// the link for %a, Clocked and ClockedLink the title of and link to the
+// clocked-in task and File the target file. Shell enables %(command)
+// escapes, which run the command with sh.
+type Input struct {
+	Content     string
+	Initial     string
+	Time        time.Time
+	Prompter    Prompter
+	Link        string
+	Clocked     string
+	ClockedLink string
+	File        string
+	Shell       bool
+}
+
+// Entry expands the template for in and returns the text to insert, ending
+// with a newline. Items get a bullet, or a checkbox for checkitems, and table
+// lines the bars of a row when the format does not start with one. The
+// warnings are meant for the user, e.g. about a %a escape without a link.
+func (t Template) Entry(in Input) (string, []string, error) {
+	format := t.Format
+	if format == "" {
+		format = defaultFormats[t.Type]
+	}
+	if in.Prompter == nil {
+		in.Prompter = Answers{}
+	}
+	if in.File == "" {
+		in.File = FormatAt(t.File, "", in.Time)
+	}
+	entry, props, warnings, err := expand(format, in)
 	if err != nil {
-		cwd = "unknown"
-	}
-	cwdLink := fmt.Sprintf("[[file:%s][%s]]", cwd, cwd)
-
-	replacements := []string{
-		"%c", content,
-		"%t", now.Format("<2006-01-02 Mon 15:04>"),
-		"%L", cwdLink,
-		"%Y", now.Format("2006"),
-		"%y", now.Format("06"),
-		"%m", now.Format("01"),
-		"%d", now.Format("02"),
-		"%H", now.Format("15"),
-		"%M", now.Format("04"),
-		"%S", now.Format("05"),
-		"%A", now.Format("Monday"),
-		"%a", now.Format("Mon"),
-	}
-
-	replacer := strings.NewReplacer(replacements...)
-	return replacer.Replace(template)
+		return "", nil, err
+	}
+	entry = strings.TrimRight(entry, "\n")
+	if len(props) > 0 {
+		if entry, err = setEntryProperties(entry, props); err != nil {
+			return "", nil, err
+		}
+	}
+
+	switch t.Type {
+	case TypeEntry:
+		first := strings.TrimLeft(entry, "\n")
+		if i := strings.Index(first, "\n"); i != -1 {
+			first = first[:i]
+		}
+		if parser.ParseHeadline(first) == nil {
+			return "", nil, fmt.Errorf("capture template %s does not start with a headline", t.Key)
+		}
+	case TypeItem:
+		if !listItemRegex.MatchString(entry) {
+			entry = "- " + entry
+		}
+	case TypeCheckItem:
+		if !checkItemRegex.MatchString(entry) {
+			entry = "- [ ] " + strings.TrimSpace(listItemRegex.ReplaceAllString(entry, ""))
+		}
+	case TypeTableLine:
+		if !tableRowRegex.MatchString(entry) {
+			entry = "| " + strings.TrimSpace(entry) + " |"
+		}
+	}
+
+	blank := strings.Repeat("\n", t.EmptyLines)
+	return blank + entry + "\n" + blank, warnings, nil
+}
+
+// Insert returns content, the content of File, with entry inserted at the
+// target of the template. Date trees file the entry under the day of now.
+// Items and table lines go into the first plain list or table in the body of
+// the target, which is started when there is none.
+func (t Template) Insert(content string, entry string, now time.Time) (string, error) {
+	lines := contentLines(content)
+	var idx int
+	switch t.Target {
+	case TargetDatetree, TargetWeektree:
+		lines, idx = findDatetreeInsertionPoint(lines, datetreePath(now, t.Target == TargetWeektree))
+	case TargetID:
+		if idx = FindID(lines, t.ID); idx == -1 {
+			return "", fmt.Errorf("no headline with ID %q found in %s", t.ID, t.File)
+		}
+	default:
+		heading, olp := t.Location()
+		var err error
+		if idx, err = findTarget(lines, t.File, heading, olp); err != nil {
+			return "", err
+		}
+	}
+
+	switch t.Type {
+	case TypeItem, TypeCheckItem:
+		return insertListItem(lines, idx, entry, t.Prepend), nil
+	case TypeTableLine:
+		return insertTableLine(lines, idx, entry, t.Prepend), nil
+	}
+	return insertEntry(lines, idx, entry, t.Prepend), nil
+}
+
+// FindID returns the index of the headline of lines whose :ID: is id, or
+// -1. Like Org links, "id:<id>" is accepted too, and "#<id>" matches
+// :CUSTOM_ID: only.
+func FindID(lines []string, id string) int {
+	id = strings.TrimSpace(id)
+	keys := []string{"ID", "CUSTOM_ID"}
+	if custom, ok := strings.CutPrefix(id, "#"); ok {
+		id, keys = custom, keys[1:]
+	} else {
+		id = strings.TrimPrefix(id, "id:")
+	}
+	for i, line := range lines {
+		if !edit.IsHeadline(line) {
+			continue
+		}
+		for _, key := range keys {
+			if value, ok := edit.GetProperty(lines, i, key); ok && value == id {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Location returns the heading or outline path below which entries are
+// inserted, as expected by findTarget.
+func (t Template) Location() (string, []string) {
+	switch t.Target {
+	case TargetHeadline:
+		return t.Headline, nil
+	case TargetOLP:
+		return "", t.OLP
+	case "":
+		return t.Headline, t.OLP
+	}
+	return "", nil
 }
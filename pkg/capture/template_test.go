@@ -3,6 +3,7 @@ package capture
 import (
 	"regexp"
 	"testing"
+	"time"
 )
 
 func TestFormat(t *testing.T) {
@@ -39,7 +40,7 @@ func TestFormat(t *testing.T) {
 		},
 		{
 			name:      "Extended Date Formats",
-			template:  "%Y-%m-%d %H:%M:%S %A %a",
+			template:  "%Y-%m-%d %H:%M:%S %A %<%a>",
 			content:   "Ignored",
 			wantRegex: `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} \w+ \w{3}`,
 		},
@@ -77,3 +78,63 @@ func TestFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplate_Entry(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    Template
+		want    string
+		wantErr bool
+	}{
+		{name: "Entry", tmpl: Template{Type: TypeEntry, Format: "* TODO %c"}, want: "* TODO Call\n"},
+		{name: "Default entry format", tmpl: Template{Type: TypeEntry}, want: "* Call\n"},
+		{name: "Entry without headline", tmpl: Template{Type: TypeEntry, Format: "%c"}, wantErr: true},
+		{name: "Item", tmpl: Template{Type: TypeItem, Format: "%c"}, want: "- Call\n"},
+		{name: "Item with bullet", tmpl: Template{Type: TypeItem, Format: "+ %c"}, want: "+ Call\n"},
+		{name: "Checkitem", tmpl: Template{Type: TypeCheckItem}, want: "- [ ] Call\n"},
+		{name: "Checkitem from item", tmpl: Template{Type: TypeCheckItem, Format: "- %c"}, want: "- [ ] Call\n"},
+		{name: "Plain", tmpl: Template{Type: TypePlain, Format: "Note: %c\n"}, want: "Note: Call\n"},
+		{name: "Table line", tmpl: Template{Type: TypeTableLine}, want: "| Call |\n"},
+		{name: "Table line without bars", tmpl: Template{Type: TypeTableLine, Format: "%c | Bob"}, want: "| Call | Bob |\n"},
+		{name: "Empty lines", tmpl: Template{Type: TypeEntry, EmptyLines: 1}, want: "\n* Call\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := tt.tmpl.Entry(Input{Content: "Call", Time: time.Now()})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Entry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Entry() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplate_Validate(t *testing.T) {
+	valid := []Template{
+		{File: "a.org"},
+		{Key: "t", Type: TypeEntry, Target: TargetHeadline, File: "a.org", Headline: "Tasks"},
+		{Key: "p", Type: TypePlain, Target: TargetOLP, File: "a.org", OLP: []string{"A", "B"}},
+		{Key: "i", Type: TypeEntry, Target: TargetID, ID: "website"},
+	}
+	for _, tmpl := range valid {
+		if err := tmpl.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", tmpl, err)
+		}
+	}
+	invalid := []Template{
+		{Key: "x"},
+		{Key: "x", File: "a.org", Type: "table"},
+		{Key: "x", File: "a.org", Target: TargetHeadline},
+		{Key: "x", File: "a.org", Target: TargetOLP},
+		{Key: "x", Target: TargetID},
+		{Key: "x", File: "a.org", Target: "file+regexp"},
+		{Key: "x", File: "a.org", EmptyLines: -1},
+	}
+	for _, tmpl := range invalid {
+		if err := tmpl.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", tmpl)
+		}
+	}
+}
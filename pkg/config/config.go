@@ -1,21 +1,215 @@
 package config
 
 import (
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
 	"github.com/spf13/viper"
 )
 
+// Config mirrors config.yaml.
 type Config struct {
-	OrgFiles    []string      `mapstructure:"org_files"`
-	DefaultFile string        `mapstructure:"default_file"`
-	Capture     CaptureConfig `mapstructure:"capture"`
+	// OrgFiles lists the files tasks are read from.
+	OrgFiles []string `mapstructure:"org_files"`
+	// DefaultFile is the capture file when capture.default_file is unset.
+	DefaultFile string `mapstructure:"default_file"`
+	// Capture configures the capture command.
+	Capture CaptureConfig `mapstructure:"capture"`
+	// Priorities is the priority range, like #+PRIORITIES.
+	Priorities PriorityConfig `mapstructure:"priorities"`
+	// DailyCapacity is an Org duration such as "8:00" describing how much
+	// effort fits in a day.
+	DailyCapacity string `mapstructure:"daily_capacity"`
+	// AutoID assigns an :ID: to tasks on their first modification.
+	AutoID bool `mapstructure:"auto_id"`
+	// IDIndex overrides the location of the ID lookup cache.
+	IDIndex string `mapstructure:"id_index"`
+	// TodoKeywords declares keyword sequences like org-todo-keywords.
+	TodoKeywords []string `mapstructure:"todo_keywords"`
+	// LogIntoDrawer mirrors org-log-into-drawer.
+	LogIntoDrawer string `mapstructure:"log_into_drawer"`
+	// LogReschedule mirrors org-log-reschedule: "time" or "note".
+	LogReschedule string `mapstructure:"log_reschedule"`
+	// LogRedeadline mirrors org-log-redeadline: "time" or "note".
+	LogRedeadline string `mapstructure:"log_redeadline"`
+	// TagsColumn mirrors org-tags-column and is nil when not configured.
+	TagsColumn *int `mapstructure:"tags_column"`
+	// RefileTargets mirrors org-refile-targets.
+	RefileTargets []RefileTargetConfig `mapstructure:"refile_targets"`
+	// ArchiveLocation mirrors org-archive-location.
+	ArchiveLocation string `mapstructure:"archive_location"`
+	// Journal overrides the location of the undo journal.
+	Journal string `mapstructure:"journal"`
+	// NoteLocation is where notes are added: "logbook" (the default) or
+	// "body".
+	NoteLocation string `mapstructure:"note_location"`
 }
 
+// CaptureConfig configures capture. Templates lists named templates like
+// org-capture-templates; the other settings describe the single template
+// used when none are listed. ShellEscapes enables %(command) in templates.
 type CaptureConfig struct {
-	DefaultFile string   `mapstructure:"default_file"`
-	Heading     string   `mapstructure:"heading"`
+	DefaultFile  string                  `mapstructure:"default_file"`
+	Heading      string                  `mapstructure:"heading"`
+	OLP          []string                `mapstructure:"olp"`
+	Format       string                  `mapstructure:"format"`
+	Prepend      bool                    `mapstructure:"prepend"`
+	Templates    []CaptureTemplateConfig `mapstructure:"templates"`
+	ShellEscapes bool                    `mapstructure:"shell_escapes"`
+}
+
+// CaptureTemplateConfig is an entry of capture.templates. File defaults to
+// the capture or default file, Type to "entry" and Target to the kind of
+// location that is set. A target of "id:<id>" sets ID, the :ID: or
+// :CUSTOM_ID: of the headline to capture to in any file.
+type CaptureTemplateConfig struct {
+	Key         string   `mapstructure:"key"`
+	Description string   `mapstructure:"description"`
+	Type        string   `mapstructure:"type"`
+	Target      string   `mapstructure:"target"`
+	File        string   `mapstructure:"file"`
+	Headline    string   `mapstructure:"headline"`
 	OLP         []string `mapstructure:"olp"`
-	Format      string   `mapstructure:"format"`
+	ID          string   `mapstructure:"id"`
+	Template    string   `mapstructure:"template"`
 	Prepend     bool     `mapstructure:"prepend"`
+	EmptyLines  int      `mapstructure:"empty_lines"`
+}
+
+// RefileTargetConfig offers the headlines of File down to MaxLevel as refile
+// targets. An entry without File applies to every other Org file.
+type RefileTargetConfig struct {
+	File     string `mapstructure:"file"`
+	MaxLevel int    `mapstructure:"max_level"`
+}
+
+// PriorityConfig is the global equivalent of the #+PRIORITIES keyword.
+type PriorityConfig struct {
+	Highest string `mapstructure:"highest"`
+	Lowest  string `mapstructure:"lowest"`
+	Default string `mapstructure:"default"`
+}
+
+// Range returns the configured priority range, falling back to Org's A/C/B
+// defaults when the configuration is missing or inconsistent.
+func (c PriorityConfig) Range() item.PriorityRange {
+	if c.Highest == "" && c.Lowest == "" && c.Default == "" {
+		return item.DefaultPriorityRange
+	}
+	r := item.PriorityRange{Highest: c.Highest, Lowest: c.Lowest, Default: c.Default}
+	if !r.Valid() {
+		return item.DefaultPriorityRange
+	}
+	return r
+}
+
+// Keywords returns the configured TODO keywords, falling back to the defaults
+// when none are configured or the declaration is invalid.
+func (c Config) Keywords() item.Keywords {
+	if len(c.TodoKeywords) == 0 {
+		return item.DefaultKeywords
+	}
+	kws, err := parser.ParseTodoKeywords(c.TodoKeywords...)
+	if err != nil {
+		return item.DefaultKeywords
+	}
+	return kws
+}
+
+// CaptureFile returns the file captures go to when a template names none:
+// capture.default_file, default_file or the first Org file.
+func (c Config) CaptureFile() string {
+	switch {
+	case c.Capture.DefaultFile != "":
+		return c.Capture.DefaultFile
+	case c.DefaultFile != "":
+		return c.DefaultFile
+	case len(c.OrgFiles) > 0:
+		return c.OrgFiles[0]
+	}
+	return ""
+}
+
+// CaptureTemplates returns the configured capture templates. Without
+// capture.templates, the single template described by the other capture
+// settings is returned, with an empty key.
+func (c Config) CaptureTemplates() []capture.Template {
+	if len(c.Capture.Templates) == 0 {
+		return []capture.Template{{
+			Description: "Default",
+			File:        c.CaptureFile(),
+			Headline:    c.Capture.Heading,
+			OLP:         c.Capture.OLP,
+			Format:      c.Capture.Format,
+			Prepend:     c.Capture.Prepend,
+		}}
+	}
+	templates := make([]capture.Template, 0, len(c.Capture.Templates))
+	for _, t := range c.Capture.Templates {
+		tmpl := capture.Template{
+			Key:         t.Key,
+			Description: t.Description,
+			Type:        t.Type,
+			Target:      t.Target,
+			File:        t.File,
+			Headline:    t.Headline,
+			OLP:         t.OLP,
+			ID:          t.ID,
+			Format:      t.Template,
+			Prepend:     t.Prepend,
+			EmptyLines:  t.EmptyLines,
+		}
+		if tmpl.Type == "" {
+			tmpl.Type = capture.TypeEntry
+		}
+		if id, ok := strings.CutPrefix(tmpl.Target, capture.TargetID+":"); ok {
+			tmpl.Target, tmpl.ID = capture.TargetID, id
+		}
+		if tmpl.Target == "" {
+			switch {
+			case tmpl.ID != "":
+				tmpl.Target = capture.TargetID
+			case len(tmpl.OLP) > 0:
+				tmpl.Target = capture.TargetOLP
+			case tmpl.Headline != "":
+				tmpl.Target = capture.TargetHeadline
+			default:
+				tmpl.Target = capture.TargetFile
+			}
+		}
+		if tmpl.File == "" && tmpl.Target != capture.TargetID {
+			tmpl.File = c.CaptureFile()
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates
+}
+
+// LogDrawer returns the drawer state changes are logged into. Like
+// org-log-into-drawer, true selects LOGBOOK and any other value names the
+// drawer; an empty or false value logs below the headline.
+func (c Config) LogDrawer() string {
+	switch strings.ToLower(strings.TrimSpace(c.LogIntoDrawer)) {
+	case "", "false", "0", "nil":
+		return ""
+	case "true", "1", "t":
+		return "LOGBOOK"
+	}
+	return strings.TrimSpace(c.LogIntoDrawer)
+}
+
+// LogSetting converts a logging setting such as log_reschedule to one of
+// item.LogNone, LogTime or LogNote. Like Org, any true value means "time".
+func LogSetting(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "false", "0", "nil":
+		return item.LogNone
+	case "note":
+		return item.LogNote
+	}
+	return item.LogTime
 }
 
 func LoadConfig() (*Config, error) {
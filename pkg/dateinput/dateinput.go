@@ -0,0 +1,264 @@
+// Package dateinput reads dates typed by a user the way Org's org-read-date
+// does: absolute dates, offsets such as "+2d" or "-1w", weekday names, partial
+// dates and an optional time of day, all interpreted relative to a reference
+// date. Like Org with org-read-date-prefer-future, partial dates that already
+// passed refer to their next occurrence.
+package dateinput
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+var (
+	absoluteRegex = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})$`)
+	relativeRegex = regexp.MustCompile(`^([+-])(\d+)([dwmy]|[a-z]{3,})?$`)
+	monthDayRegex = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?$`)
+	dayRegex      = regexp.MustCompile(`^(\d{1,2})$`)
+	clockRegex    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+)
+
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// Date is the result of reading a date. HasTime reports whether a time of
+// day was given, in which case Time carries it.
+type Date struct {
+	Time    time.Time
+	HasTime bool
+}
+
+// Timestamp renders d as an active Org timestamp such as "<2026-01-05 Mon>"
+// or "<2026-01-05 Mon 10:00>".
+func (d Date) Timestamp() string {
+	return parser.FormatTimestamp(d.Time, true, d.HasTime)
+}
+
+// Parse reads input relative to ref. Input consists of at most one date and
+// one time of day, in either order:
+//
+//	2026-01-05       an absolute date
+//	today, .         ref itself; tomorrow and yesterday work as well
+//	+3, +2d, -1w     an offset from ref in days, weeks, months or years
+//	+2tue            the second Tuesday after ref
+//	fri              the nearest Friday on or after ref
+//	next mon         the first Monday after ref
+//	2/14, 2/14/2027  month and day, with an optional year
+//	14               a day of the current (or next) month
+//	10:00, 10am      a time of day; the date defaults to ref
+//
+// An Org timestamp such as "<2026-01-05 Mon 10:00>" is accepted as well; the
+// day name following an absolute date is ignored.
+func Parse(input string, ref time.Time) (Date, error) {
+	input = strings.TrimSpace(input)
+	if len(input) >= 2 && (input[0] == '<' && input[len(input)-1] == '>' || input[0] == '[' && input[len(input)-1] == ']') {
+		input = input[1 : len(input)-1]
+	}
+	fields := strings.Fields(strings.ToLower(input))
+	if len(fields) == 0 {
+		return Date{}, fmt.Errorf("empty date")
+	}
+
+	day := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+	date := day
+	haveDate := false
+	var hour, minute int
+	haveTime := false
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		if h, m, ok := parseClock(field); ok {
+			if haveTime {
+				return Date{}, fmt.Errorf("more than one time of day in %q", input)
+			}
+			hour, minute, haveTime = h, m, true
+			continue
+		}
+
+		if haveDate {
+			return Date{}, fmt.Errorf("more than one date in %q", input)
+		}
+		haveDate = true
+
+		if field == "next" && i+1 < len(fields) {
+			wd, ok := parseWeekday(fields[i+1])
+			if !ok {
+				return Date{}, fmt.Errorf("expected a weekday after \"next\" in %q", input)
+			}
+			i++
+			date = nextWeekday(day, wd, false)
+			continue
+		}
+		d, err := parseDate(field, day)
+		if err != nil {
+			return Date{}, fmt.Errorf("invalid date %q: %w", input, err)
+		}
+		date = d
+		if absoluteRegex.MatchString(field) && i+1 < len(fields) {
+			if _, ok := parseWeekday(fields[i+1]); ok {
+				i++
+			}
+		}
+	}
+
+	if haveTime {
+		date = time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location())
+	}
+	return Date{Time: date, HasTime: haveTime}, nil
+}
+
+// parseDate reads a single date token relative to day.
+func parseDate(field string, day time.Time) (time.Time, error) {
+	switch field {
+	case "today", ".":
+		return day, nil
+	case "tomorrow":
+		return day.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return day.AddDate(0, 0, -1), nil
+	}
+
+	if m := absoluteRegex.FindStringSubmatch(field); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		dom, _ := strconv.Atoi(m[3])
+		return validDate(year, month, dom, day.Location())
+	}
+	if m := relativeRegex.FindStringSubmatch(field); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+		if wd, ok := parseWeekday(m[3]); ok {
+			return nthWeekday(day, wd, n), nil
+		}
+		if len(m[3]) > 1 {
+			return time.Time{}, fmt.Errorf("unknown unit %q", m[3])
+		}
+		return offset(day, n, m[3]), nil
+	}
+	if m := monthDayRegex.FindStringSubmatch(field); m != nil {
+		month, _ := strconv.Atoi(m[1])
+		dom, _ := strconv.Atoi(m[2])
+		if m[3] != "" {
+			year, _ := strconv.Atoi(m[3])
+			if len(m[3]) == 2 {
+				year += 2000
+			}
+			return validDate(year, month, dom, day.Location())
+		}
+		t, err := validDate(day.Year(), month, dom, day.Location())
+		if err == nil && t.Before(day) {
+			return validDate(day.Year()+1, month, dom, day.Location())
+		}
+		return t, err
+	}
+	if m := dayRegex.FindStringSubmatch(field); m != nil {
+		dom, _ := strconv.Atoi(m[1])
+		t, err := validDate(day.Year(), int(day.Month()), dom, day.Location())
+		if err == nil && t.Before(day) {
+			next := day.AddDate(0, 0, -day.Day()+1).AddDate(0, 1, 0)
+			return validDate(next.Year(), int(next.Month()), dom, day.Location())
+		}
+		return t, err
+	}
+	if wd, ok := parseWeekday(field); ok {
+		return nextWeekday(day, wd, true), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized input %q", field)
+}
+
+// nthWeekday returns the nth occurrence of wd after day, or before it when n
+// is negative.
+func nthWeekday(day time.Time, wd time.Weekday, n int) time.Time {
+	if n < 0 {
+		delta := (int(day.Weekday()) - int(wd) + 7) % 7
+		if delta == 0 {
+			delta = 7
+		}
+		return day.AddDate(0, 0, -delta+7*(n+1))
+	}
+	if n == 0 {
+		n = 1
+	}
+	return nextWeekday(day, wd, false).AddDate(0, 0, 7*(n-1))
+}
+
+// offset moves day by n units, where unit is one of d, w, m or y.
+func offset(day time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "w":
+		return day.AddDate(0, 0, 7*n)
+	case "m":
+		return day.AddDate(0, n, 0)
+	case "y":
+		return day.AddDate(n, 0, 0)
+	}
+	return day.AddDate(0, 0, n)
+}
+
+// validDate builds a date, rejecting values that time.Date would normalize
+// such as February 30th.
+func validDate(year, month, dom int, loc *time.Location) (time.Time, error) {
+	t := time.Date(year, time.Month(month), dom, 0, 0, 0, 0, loc)
+	if t.Year() != year || int(t.Month()) != month || t.Day() != dom {
+		return time.Time{}, fmt.Errorf("no such date %04d-%02d-%02d", year, month, dom)
+	}
+	return t, nil
+}
+
+// parseWeekday accepts English weekday names and their prefixes of at least
+// three letters.
+func parseWeekday(field string) (time.Weekday, bool) {
+	if len(field) < 3 {
+		return 0, false
+	}
+	for i, name := range weekdays {
+		if strings.HasPrefix(name, field) {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+// nextWeekday returns the first day after day (or on it, when inclusive)
+// that falls on wd.
+func nextWeekday(day time.Time, wd time.Weekday, inclusive bool) time.Time {
+	delta := (int(wd) - int(day.Weekday()) + 7) % 7
+	if delta == 0 && !inclusive {
+		delta = 7
+	}
+	return day.AddDate(0, 0, delta)
+}
+
+// parseClock reads a time of day such as "9:30", "15:00", "10am" or
+// "3:30pm". A bare number is a day of the month, not a time.
+func parseClock(field string) (int, int, bool) {
+	m := clockRegex.FindStringSubmatch(field)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, 0, false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if m[3] != "" {
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
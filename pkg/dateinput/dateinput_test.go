@@ -0,0 +1,68 @@
+package dateinput
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Monday
+	ref := time.Date(2026, 1, 5, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2026-02-14", "<2026-02-14 Sat>"},
+		{"+2d", "<2026-01-07 Wed>"},
+		{"-1w", "<2025-12-29 Mon>"},
+		{"+1m", "<2026-02-05 Thu>"},
+		{"+1y", "<2027-01-05 Tue>"},
+		{"fri", "<2026-01-09 Fri>"},
+		{"Friday", "<2026-01-09 Fri>"},
+		{"mon", "<2026-01-05 Mon>"},
+		{"next mon", "<2026-01-12 Mon>"},
+		{"next tue", "<2026-01-06 Tue>"},
+		{"+1w 10:00", "<2026-01-12 Mon 10:00>"},
+		{"9:05 fri", "<2026-01-09 Fri 09:05>"},
+		{"18:00", "<2026-01-05 Mon 18:00>"},
+		{"today", "<2026-01-05 Mon>"},
+		{".", "<2026-01-05 Mon>"},
+		{"tomorrow", "<2026-01-06 Tue>"},
+		{"yesterday", "<2026-01-04 Sun>"},
+		{"+3", "<2026-01-08 Thu>"},
+		{"-1", "<2026-01-04 Sun>"},
+		{"+2tue", "<2026-01-13 Tue>"},
+		{"-1mon", "<2025-12-29 Mon>"},
+		{"2/14", "<2026-02-14 Sat>"},
+		{"1/1", "<2027-01-01 Fri>"},
+		{"2/14/27", "<2027-02-14 Sun>"},
+		{"14", "<2026-01-14 Wed>"},
+		{"3", "<2026-02-03 Tue>"},
+		{"10am", "<2026-01-05 Mon 10:00>"},
+		{"12am", "<2026-01-05 Mon 00:00>"},
+		{"3:30pm tomorrow", "<2026-01-06 Tue 15:30>"},
+		{"+2d 15:00", "<2026-01-07 Wed 15:00>"},
+		{"<2026-01-09 Fri 10:00>", "<2026-01-09 Fri 10:00>"},
+		{"2026-01-09 fri", "<2026-01-09 Fri>"},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.input, ref)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := d.Timestamp(); got != tt.expected {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	ref := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{"", "soon", "2026-02-30", "+2d fri", "10:00 11:00", "next", "next week", "25:00", "13pm", "2/30", "+2x", "32"} {
+		if d, err := Parse(input, ref); err == nil {
+			t.Errorf("Parse(%q) = %s, expected an error", input, d.Timestamp())
+		}
+	}
+}
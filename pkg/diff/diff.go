@@ -0,0 +1,279 @@
+// Package diff computes line-based differences between two versions of a
+// file and applies them again, in either direction.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hunk replaces the lines Old, found at the 0-based index OldStart of the
+// original, with New, which start at index NewStart of the result.
+type Hunk struct {
+	OldStart int      `json:"oldStart"`
+	Old      []string `json:"old,omitempty"`
+	NewStart int      `json:"newStart"`
+	New      []string `json:"new,omitempty"`
+}
+
+// Compute returns the hunks that turn a into b, in file order. Unchanged
+// lines are not part of any hunk.
+func Compute(a, b []string) []Hunk {
+	var hunks []Hunk
+	var current *Hunk
+	x, y := 0, 0
+	for _, op := range editScript(a, b) {
+		if op == opEqual {
+			if current != nil {
+				hunks = append(hunks, *current)
+				current = nil
+			}
+			x++
+			y++
+			continue
+		}
+		if current == nil {
+			current = &Hunk{OldStart: x, NewStart: y}
+		}
+		if op == opDelete {
+			current.Old = append(current.Old, a[x])
+			x++
+		} else {
+			current.New = append(current.New, b[y])
+			y++
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+	return hunks
+}
+
+// Reverse returns the hunks that undo hunks.
+func Reverse(hunks []Hunk) []Hunk {
+	reversed := make([]Hunk, len(hunks))
+	for i, h := range hunks {
+		reversed[i] = Hunk{OldStart: h.NewStart, Old: h.New, NewStart: h.OldStart, New: h.Old}
+	}
+	return reversed
+}
+
+// Apply applies hunks to lines. It fails when the lines a hunk replaces are
+// not where the hunk expects them.
+func Apply(lines []string, hunks []Hunk) ([]string, error) {
+	result := make([]string, 0, len(lines))
+	pos := 0
+	for _, h := range hunks {
+		if h.OldStart < pos || h.OldStart+len(h.Old) > len(lines) {
+			return nil, fmt.Errorf("hunk at line %d is out of range", h.OldStart+1)
+		}
+		for i, line := range h.Old {
+			if lines[h.OldStart+i] != line {
+				return nil, fmt.Errorf("line %d does not match", h.OldStart+i+1)
+			}
+		}
+		result = append(result, lines[pos:h.OldStart]...)
+		result = append(result, h.New...)
+		pos = h.OldStart + len(h.Old)
+	}
+	return append(result, lines[pos:]...), nil
+}
+
+// Unified renders the difference between a and b as a unified diff labelled
+// with fromFile and toFile, showing context unchanged lines around each
+// change. It returns "" when a and b are equal.
+func Unified(fromFile, toFile string, a, b []string, context int) string {
+	ops := editScript(a, b)
+	// x[i] and y[i] are the positions in a and b before ops[i].
+	x, y := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, o := range ops {
+		x[i+1], y[i+1] = x[i], y[i]
+		if o != opInsert {
+			x[i+1]++
+		}
+		if o != opDelete {
+			y[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i] == opEqual {
+			i++
+			continue
+		}
+		// Extend the hunk over changes separated by at most 2*context
+		// unchanged lines.
+		last := i
+		for j := i; j < len(ops) && j <= last+2*context+1; j++ {
+			if ops[j] != opEqual {
+				last = j
+			}
+		}
+		start, end := max(i-context, 0), min(last+context+1, len(ops))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromFile, toFile)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(x[start], x[end]-x[start]), hunkRange(y[start], y[end]-y[start]))
+		for j := start; j < end; j++ {
+			switch ops[j] {
+			case opEqual:
+				sb.WriteString(" " + a[x[j]] + "\n")
+			case opDelete:
+				sb.WriteString("-" + a[x[j]] + "\n")
+			case opInsert:
+				sb.WriteString("+" + b[y[j]] + "\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the 0-based start and the length of a hunk side the way
+// unified diffs do: 1-based, with the line before for empty ranges.
+func hunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+type op int
+
+const (
+	opEqual op = iota
+	opDelete
+	opInsert
+)
+
+// editScript returns a shortest edit script from a to b using Myers'
+// algorithm in its linear space variant, which splits the problem at the
+// middle snake of the edit graph instead of keeping every step of the
+// search.
+func editScript(a, b []string) []op {
+	return appendScript(make([]op, 0, max(len(a), len(b))), a, b)
+}
+
+// appendScript appends the edit script from a to b to ops.
+func appendScript(ops []op, a, b []string) []op {
+	// Common lines at both ends need no search.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops = appendOps(ops, opEqual, prefix)
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		ops = appendOps(ops, opInsert, len(b))
+	case len(b) == 0:
+		ops = appendOps(ops, opDelete, len(a))
+	default:
+		if x, y, ok := middleSnake(a, b); ok {
+			ops = appendScript(ops, a[:x], b[:y])
+			ops = appendScript(ops, a[x:], b[y:])
+		} else {
+			ops = appendOps(ops, opDelete, len(a))
+			ops = appendOps(ops, opInsert, len(b))
+		}
+	}
+	return appendOps(ops, opEqual, suffix)
+}
+
+func appendOps(ops []op, o op, n int) []op {
+	for i := 0; i < n; i++ {
+		ops = append(ops, o)
+	}
+	return ops
+}
+
+// middleSnake searches shortest paths from both corners of the edit graph
+// of a and b at once and returns the point where they meet, which splits
+// the problem in two. a and b must differ in their first and last lines.
+// ok is false when no split point is found, i.e. a and b have nothing in
+// common.
+func middleSnake(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	// vf[k] and vb[k] are the furthest x reached on diagonal k forward and,
+	// counted from the end, backward; -1 when not reached yet.
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+	delta := n - m
+	// With an odd delta the paths meet on a forward step, otherwise on a
+	// backward one.
+	front := delta%2 != 0
+	// Diagonals that ran off the graph are not searched again.
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x1 int
+			if k == -d || (k != d && vf[i-1] < vf[i+1]) {
+				x1 = vf[i+1]
+			} else {
+				x1 = vf[i-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			vf[i] = x1
+			switch {
+			case x1 > n:
+				fEnd += 2
+			case y1 > m:
+				fStart += 2
+			case front:
+				if j := offset + delta - k; j >= 0 && j < len(vb) && vb[j] != -1 && x1 >= n-vb[j] {
+					return x1, y1, true
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x2 int
+			if k == -d || (k != d && vb[i-1] < vb[i+1]) {
+				x2 = vb[i+1]
+			} else {
+				x2 = vb[i-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+			vb[i] = x2
+			switch {
+			case x2 > n:
+				bEnd += 2
+			case y2 > m:
+				bStart += 2
+			case !front:
+				if j := offset + delta - k; j >= 0 && j < len(vf) && vf[j] != -1 && vf[j] >= n-x2 {
+					x1 := vf[j]
+					return x1, x1 - (j - offset), true
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
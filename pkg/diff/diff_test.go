@@ -0,0 +1,178 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	a := strings.Split("* TODO A\n* TODO B\nbody\n* TODO C", "\n")
+	b := strings.Split("* TODO A\n* DONE B\nbody\n* TODO C\n* TODO D", "\n")
+
+	hunks := Compute(a, b)
+	expected := []Hunk{
+		{OldStart: 1, Old: []string{"* TODO B"}, NewStart: 1, New: []string{"* DONE B"}},
+		{OldStart: 4, NewStart: 4, New: []string{"* TODO D"}},
+	}
+	if !reflect.DeepEqual(hunks, expected) {
+		t.Errorf("Compute() = %+v, want %+v", hunks, expected)
+	}
+}
+
+func TestApplyRoundTrip(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"", "* TODO A"},
+		{"* TODO A", ""},
+		{"a\nb\nc\nd\ne", "a\nx\nc\ny\nz\ne"},
+		{"a\nb\nc", "c\nb\na"},
+		{"same\nlines", "same\nlines"},
+	}
+	for _, tc := range cases {
+		a, b := strings.Split(tc.a, "\n"), strings.Split(tc.b, "\n")
+		hunks := Compute(a, b)
+
+		got, err := Apply(a, hunks)
+		if err != nil || !reflect.DeepEqual(got, b) {
+			t.Errorf("Apply(%q) = %q, %v; want %q", tc.a, got, err, tc.b)
+		}
+		got, err = Apply(b, Reverse(hunks))
+		if err != nil || !reflect.DeepEqual(got, a) {
+			t.Errorf("Apply(Reverse) on %q = %q, %v; want %q", tc.b, got, err, tc.a)
+		}
+	}
+}
+
+func TestApplyMismatch(t *testing.T) {
+	hunks := Compute([]string{"a", "b"}, []string{"a", "c"})
+	if _, err := Apply([]string{"a", "x"}, hunks); err == nil {
+		t.Error("Expected an error when the replaced lines differ")
+	}
+	if _, err := Apply([]string{"a"}, hunks); err == nil {
+		t.Error("Expected an error for a hunk out of range")
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}
+	b := []string{"1", "2", "three", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13"}
+
+	want := `--- a.org
++++ b.org
+@@ -1,5 +1,5 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+@@ -11,2 +11,3 @@
+ 11
+ 12
++13
+`
+	if got := Unified("a.org", "b.org", a, b, 2); got != want {
+		t.Errorf("Unified() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Changes closer than twice the context share a hunk.
+	want = `--- a.org
++++ b.org
+@@ -1,12 +1,13 @@
+ 1
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+ 9
+ 10
+ 11
+ 12
++13
+`
+	b = []string{"1", "2", "3", "4", "five", "6", "7", "8", "9", "10", "11", "12", "13"}
+	if got := Unified("a.org", "b.org", a, b, 4); got != want {
+		t.Errorf("Unified() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := Unified("/dev/null", "new.org", nil, []string{"* Entry"}, 3); got != "--- /dev/null\n+++ new.org\n@@ -0,0 +1 @@\n+* Entry\n" {
+		t.Errorf("Unified() for a new file = %q", got)
+	}
+	if got := Unified("a.org", "b.org", a, a, 3); got != "" {
+		t.Errorf("Expected no diff for equal input, got %q", got)
+	}
+}
+
+func TestComputeShortest(t *testing.T) {
+	// Compare the number of changed lines with the longest common
+	// subsequence for small inputs over a tiny alphabet, where ties abound.
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return lines
+	}
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		hunks := Compute(a, b)
+		if got, err := Apply(a, hunks); err != nil || !reflect.DeepEqual(got, b) {
+			t.Fatalf("Apply(%q, Compute(%q)) = %q, %v", a, b, got, err)
+		}
+		changed := 0
+		for _, h := range hunks {
+			changed += len(h.Old) + len(h.New)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changed != want {
+			t.Fatalf("Compute(%q, %q) changes %d lines, want %d", a, b, changed, want)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestComputeLargeFile(t *testing.T) {
+	// Deleting a large subtree from a large file, as archiving or refiling
+	// does, must not need memory quadratic in the size of the change.
+	a := make([]string, 20000)
+	for i := range a {
+		a[i] = fmt.Sprintf("* TODO Task %d", i)
+	}
+	b := append(append([]string(nil), a[:5000]...), a[7000:]...)
+	b[10000] = "* DONE Task 12000"
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	hunks := Compute(a, b)
+	runtime.ReadMemStats(&after)
+
+	if len(hunks) != 2 || len(hunks[0].Old) != 2000 || len(hunks[1].New) != 1 {
+		t.Fatalf("Unexpected hunks: %d", len(hunks))
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("Compute allocated %d MB", allocated>>20)
+	}
+}
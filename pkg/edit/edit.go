@@ -0,0 +1,354 @@
+// Package edit implements in-place modifications of Org content. Functions
+// operate on the lines of a file and take the 0-based index of the headline
+// they apply to, so that callers decide how the file is read and written.
+package edit
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+var (
+	planningRegex = regexp.MustCompile(`^\s*(?:SCHEDULED|DEADLINE|CLOSED):`)
+	propertyRegex = regexp.MustCompile(`^(\s*):([^:\s]+):(?:\s+(.*?))?\s*$`)
+	closedRegex   = regexp.MustCompile(`CLOSED:\s*\[[^\]]*\]\s*`)
+	cookieRegex   = regexp.MustCompile(`^\[#[^\]]\][ \t]*`)
+	tagsRegex     = regexp.MustCompile(`[ \t]+:([[:alnum:]_@#%:]+):[ \t]*$`)
+	tagRegex      = regexp.MustCompile(`^[[:alnum:]_@#%]+$`)
+)
+
+// DefaultTagsColumn is Org's default org-tags-column: tags are right-aligned
+// so that they end in column 77.
+const DefaultTagsColumn = -77
+
+// IsHeadline reports whether line starts an Org headline.
+func IsHeadline(line string) bool {
+	return parser.ParseHeadline(line) != nil
+}
+
+// HeadlineLevel returns the number of leading stars of a headline, or 0 when
+// line is not a headline.
+func HeadlineLevel(line string) int {
+	it := parser.ParseHeadline(line)
+	if it == nil {
+		return 0
+	}
+	return it.Level
+}
+
+// SetKeyword replaces the TODO keyword of the headline line. current is the
+// keyword the headline carries now ("" for none) and next may be "" to drop
+// the keyword altogether.
+func SetKeyword(line, current, next string) string {
+	stars := strings.IndexFunc(line, func(r rune) bool { return r != '*' })
+	if stars == -1 {
+		return line
+	}
+	rest := strings.TrimLeft(line[stars:], " \t")
+	if current != "" {
+		rest = strings.TrimLeft(strings.TrimPrefix(rest, current), " \t")
+	}
+	if next != "" {
+		if rest == "" {
+			return line[:stars] + " " + next
+		}
+		return line[:stars] + " " + next + " " + rest
+	}
+	return line[:stars] + " " + rest
+}
+
+// SetPriority sets the priority cookie of the headline line, which carries
+// the TODO keyword keyword ("" for none). An empty priority removes the
+// cookie.
+func SetPriority(line, keyword, priority string) string {
+	stars := strings.IndexFunc(line, func(r rune) bool { return r != '*' })
+	if stars == -1 {
+		return line
+	}
+	prefix := line[:stars] + " "
+	rest := strings.TrimLeft(line[stars:], " \t")
+	if keyword != "" && strings.HasPrefix(rest, keyword) {
+		prefix += keyword + " "
+		rest = strings.TrimLeft(rest[len(keyword):], " \t")
+	}
+	rest = cookieRegex.ReplaceAllString(rest, "")
+	if priority != "" {
+		prefix += "[#" + priority + "] "
+	}
+	if rest == "" {
+		return strings.TrimRight(prefix, " ")
+	}
+	return prefix + rest
+}
+
+// SetTags replaces the tags of the headline line with tags, aligned like
+// org-tags-column: a positive column is where the tags start, a negative one
+// where they end and 0 separates them from the title by a single space.
+func SetTags(line string, tags []string, column int) string {
+	line = strings.TrimRight(tagsRegex.ReplaceAllString(line, ""), " \t")
+	if len(tags) == 0 {
+		return line
+	}
+	tagStr := ":" + strings.Join(tags, ":") + ":"
+	width := utf8.RuneCountInString(line)
+	pad := 1
+	switch {
+	case column > 0:
+		pad = max(1, column-width)
+	case column < 0:
+		pad = max(1, -column-width-utf8.RuneCountInString(tagStr))
+	}
+	return line + strings.Repeat(" ", pad) + tagStr
+}
+
+// ValidTag reports whether tag can be used as an Org tag, which allows
+// letters, digits and the characters _@#%.
+func ValidTag(tag string) bool {
+	return tagRegex.MatchString(tag)
+}
+
+// EntryEnd returns the index of the line following the body of the headline
+// at idx, i.e. the next headline of any level or len(lines).
+func EntryEnd(lines []string, idx int) int {
+	for i := idx + 1; i < len(lines); i++ {
+		if IsHeadline(lines[i]) {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// SubtreeEnd returns the index of the line following the subtree rooted at
+// the headline at idx, i.e. the next headline whose level is not deeper.
+func SubtreeEnd(lines []string, idx int) int {
+	level := HeadlineLevel(lines[idx])
+	for i := idx + 1; i < len(lines); i++ {
+		if l := HeadlineLevel(lines[i]); l > 0 && l <= level {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// PlanningLine returns the index of the planning line (SCHEDULED, DEADLINE,
+// CLOSED) directly below the headline at idx, or -1 when there is none.
+func PlanningLine(lines []string, idx int) int {
+	if idx+1 < len(lines) && planningRegex.MatchString(lines[idx+1]) {
+		return idx + 1
+	}
+	return -1
+}
+
+// metadataEnd returns the index right after the headline and its planning
+// line, which is where Org expects drawers to start.
+func metadataEnd(lines []string, idx int) int {
+	if p := PlanningLine(lines, idx); p != -1 {
+		return p + 1
+	}
+	return idx + 1
+}
+
+// PropertyDrawer returns the indices of the :PROPERTIES: and :END: lines of
+// the headline at idx, or (-1, -1) when the entry has no property drawer.
+func PropertyDrawer(lines []string, idx int) (int, int) {
+	start := metadataEnd(lines, idx)
+	if start >= len(lines) || !strings.EqualFold(strings.TrimSpace(lines[start]), ":PROPERTIES:") {
+		return -1, -1
+	}
+	end := EntryEnd(lines, idx)
+	for i := start + 1; i < end; i++ {
+		if strings.EqualFold(strings.TrimSpace(lines[i]), ":END:") {
+			return start, i
+		}
+	}
+	return -1, -1
+}
+
+// GetProperty returns the value of key in the property drawer of the
+// headline at idx. Keys are compared case-insensitively.
+func GetProperty(lines []string, idx int, key string) (string, bool) {
+	start, end := PropertyDrawer(lines, idx)
+	for i := start + 1; start != -1 && i < end; i++ {
+		m := propertyRegex.FindStringSubmatch(lines[i])
+		if m != nil && strings.EqualFold(m[2], key) {
+			return m[3], true
+		}
+	}
+	return "", false
+}
+
+// SetProperty sets key to value in the property drawer of the headline at
+// idx, creating the drawer right after the planning line when it is missing.
+func SetProperty(lines []string, idx int, key, value string) []string {
+	newLine := ":" + key + ": " + value
+	start, end := PropertyDrawer(lines, idx)
+	if start == -1 {
+		at := metadataEnd(lines, idx)
+		return InsertLines(lines, at, ":PROPERTIES:", newLine, ":END:")
+	}
+
+	for i := start + 1; i < end; i++ {
+		m := propertyRegex.FindStringSubmatch(lines[i])
+		if m != nil && strings.EqualFold(m[2], key) {
+			lines[i] = m[1] + ":" + m[2] + ": " + value
+			return lines
+		}
+	}
+	indent := lines[start][:len(lines[start])-len(strings.TrimLeft(lines[start], " \t"))]
+	return InsertLines(lines, end, indent+newLine)
+}
+
+// DeleteProperty removes key from the property drawer of the headline at idx
+// and reports whether it was set. A drawer left empty is removed as well.
+func DeleteProperty(lines []string, idx int, key string) ([]string, bool) {
+	start, end := PropertyDrawer(lines, idx)
+	for i := start + 1; start != -1 && i < end; i++ {
+		m := propertyRegex.FindStringSubmatch(lines[i])
+		if m == nil || !strings.EqualFold(m[2], key) {
+			continue
+		}
+		if end-start == 2 {
+			return append(lines[:start], lines[end+1:]...), true
+		}
+		return append(lines[:i], lines[i+1:]...), true
+	}
+	return lines, false
+}
+
+// SetClosed records ts as the CLOSED timestamp of the headline at idx. Like
+// Org, CLOSED goes first on the planning line, which is created if needed.
+func SetClosed(lines []string, idx int, ts string) []string {
+	p := PlanningLine(lines, idx)
+	if p == -1 {
+		return InsertLines(lines, idx+1, "CLOSED: "+ts)
+	}
+	line := closedRegex.ReplaceAllString(lines[p], "")
+	rest := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(rest)]
+	lines[p] = strings.TrimRight(indent+"CLOSED: "+ts+" "+rest, " \t")
+	return lines
+}
+
+// RemoveClosed drops the CLOSED timestamp of the headline at idx, removing
+// the planning line when nothing else is left on it.
+func RemoveClosed(lines []string, idx int) []string {
+	return SetPlanning(lines, idx, "CLOSED", "")
+}
+
+// planningEntryRegex matches key and its timestamp on a planning line.
+func planningEntryRegex(key string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(key) + `:\s*(<[^>]*>|\[[^\]]*\])\s*`)
+}
+
+// PlanningTimestamp returns the timestamp recorded for key (SCHEDULED,
+// DEADLINE or CLOSED) on the planning line of the headline at idx, or "".
+func PlanningTimestamp(lines []string, idx int, key string) string {
+	p := PlanningLine(lines, idx)
+	if p == -1 {
+		return ""
+	}
+	if m := planningEntryRegex(key).FindStringSubmatch(lines[p]); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// SetPlanning sets key to the timestamp ts on the planning line of the
+// headline at idx. An existing entry is replaced in place, a new one is
+// appended and the planning line is created when missing. An empty ts removes
+// the entry, and the planning line with it when nothing else is left.
+func SetPlanning(lines []string, idx int, key, ts string) []string {
+	re := planningEntryRegex(key)
+	p := PlanningLine(lines, idx)
+	if p == -1 {
+		if ts == "" {
+			return lines
+		}
+		return InsertLines(lines, idx+1, key+": "+ts)
+	}
+
+	line := lines[p]
+	switch {
+	case re.MatchString(line) && ts != "":
+		loc := re.FindStringSubmatchIndex(line)
+		line = line[:loc[2]] + ts + line[loc[3]:]
+	case ts != "":
+		line = strings.TrimRight(line, " \t") + " " + key + ": " + ts
+	default:
+		line = strings.TrimRight(re.ReplaceAllString(line, ""), " \t")
+	}
+
+	if strings.TrimSpace(line) == "" {
+		return append(lines[:p], lines[p+1:]...)
+	}
+	lines[p] = line
+	return lines
+}
+
+// Drawer returns the indices of the opening and :END: lines of the drawer
+// called name in the body of the headline at idx, or (-1, -1) when there is
+// none.
+func Drawer(lines []string, idx int, name string) (int, int) {
+	end := EntryEnd(lines, idx)
+	open := ":" + strings.ToUpper(name) + ":"
+	for i := idx + 1; i < end; i++ {
+		if strings.ToUpper(strings.TrimSpace(lines[i])) != open {
+			continue
+		}
+		for j := i + 1; j < end; j++ {
+			if strings.EqualFold(strings.TrimSpace(lines[j]), ":END:") {
+				return i, j
+			}
+		}
+		return -1, -1
+	}
+	return -1, -1
+}
+
+// AddLogEntry adds entry, the lines of a log note, to the headline at idx.
+// Notes are kept newest first, either in the drawer called drawer, which is
+// created after the property drawer when missing, or directly after the
+// headline's metadata when drawer is empty.
+func AddLogEntry(lines []string, idx int, drawer string, entry []string) []string {
+	at := metadataEnd(lines, idx)
+	if _, end := PropertyDrawer(lines, idx); end != -1 {
+		at = end + 1
+	}
+	if drawer == "" {
+		return InsertLines(lines, at, entry...)
+	}
+	if start, _ := Drawer(lines, idx, drawer); start != -1 {
+		return InsertLines(lines, start+1, entry...)
+	}
+	block := append([]string{":" + drawer + ":"}, entry...)
+	return InsertLines(lines, at, append(block, ":END:")...)
+}
+
+// AppendToBody adds extra at the end of the body of the headline at idx,
+// before the blank lines that separate it from the next headline.
+func AppendToBody(lines []string, idx int, extra ...string) []string {
+	at := EntryEnd(lines, idx)
+	for at > idx+1 && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+	return InsertLines(lines, at, extra...)
+}
+
+// InsertLines returns lines with extra inserted before index at.
+func InsertLines(lines []string, at int, extra ...string) []string {
+	res := make([]string, 0, len(lines)+len(extra))
+	res = append(res, lines[:at]...)
+	res = append(res, extra...)
+	return append(res, lines[at:]...)
+}
+
+// JoinLines joins lines into file content ending in a newline.
+func JoinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,226 @@
+package edit
+
+import (
+	"strings"
+	"testing"
+)
+
+func split(s string) []string {
+	return strings.Split(s, "\n")
+}
+
+func TestSetKeyword(t *testing.T) {
+	tests := []struct {
+		line, current, next, expected string
+	}{
+		{"* TODO Task", "TODO", "DONE", "* DONE Task"},
+		{"** TODO [#A] Task :tag:", "TODO", "DONE", "** DONE [#A] Task :tag:"},
+		{"* Task", "", "TODO", "* TODO Task"},
+		{"* DONE Task", "DONE", "", "* Task"},
+		{"* TODO", "TODO", "DONE", "* DONE"},
+		{"* TODO TODO list", "TODO", "DONE", "* DONE TODO list"},
+	}
+	for _, tt := range tests {
+		if got := SetKeyword(tt.line, tt.current, tt.next); got != tt.expected {
+			t.Errorf("SetKeyword(%q, %q, %q) = %q, want %q", tt.line, tt.current, tt.next, got, tt.expected)
+		}
+	}
+}
+
+func TestSubtreeEnd(t *testing.T) {
+	lines := split("* A\nbody\n** A1\n*** A11\n** A2\n* B")
+	if got := SubtreeEnd(lines, 0); got != 5 {
+		t.Errorf("SubtreeEnd(A) = %d, want 5", got)
+	}
+	if got := SubtreeEnd(lines, 2); got != 4 {
+		t.Errorf("SubtreeEnd(A1) = %d, want 4", got)
+	}
+	if got := EntryEnd(lines, 0); got != 2 {
+		t.Errorf("EntryEnd(A) = %d, want 2", got)
+	}
+}
+
+func TestSetProperty_CreatesDrawerAfterPlanning(t *testing.T) {
+	lines := split("* TODO Task\nSCHEDULED: <2026-01-05 Mon>\nBody")
+	lines = SetProperty(lines, 0, "ID", "abc")
+
+	expected := "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: abc\n:END:\nBody"
+	if got := strings.Join(lines, "\n"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestSetProperty_UpdatesExisting(t *testing.T) {
+	lines := split("* Task\n  :PROPERTIES:\n  :Owner: bob\n  :END:\n* Next")
+	lines = SetProperty(lines, 0, "OWNER", "alice")
+	lines = SetProperty(lines, 0, "TICKET", "X-1")
+
+	expected := "* Task\n  :PROPERTIES:\n  :Owner: alice\n  :TICKET: X-1\n  :END:\n* Next"
+	if got := strings.Join(lines, "\n"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	if v, ok := GetProperty(lines, 0, "ticket"); !ok || v != "X-1" {
+		t.Errorf("GetProperty(ticket) = %q, %v", v, ok)
+	}
+	if _, ok := GetProperty(lines, 5, "ID"); ok {
+		t.Error("Expected no property on the next headline")
+	}
+}
+
+func TestDeleteProperty(t *testing.T) {
+	lines := split("* Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:OWNER: bob\n:TICKET: X-1\n:END:\n:LOGBOOK:\n- note\n:END:\n* Next")
+
+	lines, ok := DeleteProperty(lines, 0, "owner")
+	expected := "* Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:TICKET: X-1\n:END:\n:LOGBOOK:\n- note\n:END:\n* Next"
+	if got := strings.Join(lines, "\n"); !ok || got != expected {
+		t.Errorf("Expected %q, got %q (%v)", expected, got, ok)
+	}
+
+	if _, ok := DeleteProperty(lines, 0, "OWNER"); ok {
+		t.Error("Expected a missing property not to be deleted")
+	}
+
+	lines, ok = DeleteProperty(lines, 0, "TICKET")
+	expected = "* Task\nSCHEDULED: <2026-01-05 Mon>\n:LOGBOOK:\n- note\n:END:\n* Next"
+	if got := strings.Join(lines, "\n"); !ok || got != expected {
+		t.Errorf("Expected the empty drawer to be removed, got %q (%v)", got, ok)
+	}
+}
+
+func TestSetClosed(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"* DONE Task\nBody", "* DONE Task\nCLOSED: [2026-01-05 Mon 10:00]\nBody"},
+		{"* DONE Task\nSCHEDULED: <2026-01-05 Mon>", "* DONE Task\nCLOSED: [2026-01-05 Mon 10:00] SCHEDULED: <2026-01-05 Mon>"},
+		{"* DONE Task\nCLOSED: [2025-12-31 Wed 09:00] DEADLINE: <2026-01-05 Mon>", "* DONE Task\nCLOSED: [2026-01-05 Mon 10:00] DEADLINE: <2026-01-05 Mon>"},
+	}
+	for _, tt := range tests {
+		lines := SetClosed(split(tt.input), 0, "[2026-01-05 Mon 10:00]")
+		if got := strings.Join(lines, "\n"); got != tt.expected {
+			t.Errorf("SetClosed(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestRemoveClosed(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"* TODO Task\nCLOSED: [2026-01-05 Mon 10:00]\nBody", "* TODO Task\nBody"},
+		{"* TODO Task\nCLOSED: [2026-01-05 Mon 10:00] SCHEDULED: <2026-01-05 Mon>", "* TODO Task\nSCHEDULED: <2026-01-05 Mon>"},
+		{"* TODO Task\nSCHEDULED: <2026-01-05 Mon>", "* TODO Task\nSCHEDULED: <2026-01-05 Mon>"},
+	}
+	for _, tt := range tests {
+		lines := RemoveClosed(split(tt.input), 0)
+		if got := strings.Join(lines, "\n"); got != tt.expected {
+			t.Errorf("RemoveClosed(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestAddLogEntry(t *testing.T) {
+	input := "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: abc\n:END:\nBody\n* Next"
+
+	lines := AddLogEntry(split(input), 0, "LOGBOOK", []string{"- first"})
+	lines = AddLogEntry(lines, 0, "LOGBOOK", []string{"- second"})
+	expected := "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: abc\n:END:\n:LOGBOOK:\n- second\n- first\n:END:\nBody\n* Next"
+	if got := strings.Join(lines, "\n"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	lines = AddLogEntry(split(input), 0, "", []string{"- first"})
+	expected = "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: abc\n:END:\n- first\nBody\n* Next"
+	if got := strings.Join(lines, "\n"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestAppendToBody(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"* TODO Task\nBody\n\n* Next", "* TODO Task\nBody\n- note\n\n* Next"},
+		{"* TODO Task\n:LOGBOOK:\n- old\n:END:\n", "* TODO Task\n:LOGBOOK:\n- old\n:END:\n- note\n"},
+		{"* TODO Task\n\n* Next", "* TODO Task\n- note\n\n* Next"},
+		{"* TODO Task", "* TODO Task\n- note"},
+	}
+	for _, tt := range tests {
+		lines := AppendToBody(split(tt.input), 0, "- note")
+		if got := strings.Join(lines, "\n"); got != tt.expected {
+			t.Errorf("AppendToBody(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSetPlanning(t *testing.T) {
+	tests := []struct {
+		input    string
+		key, ts  string
+		expected string
+	}{
+		{"* TODO Task\nBody", "SCHEDULED", "<2026-01-05 Mon>", "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\nBody"},
+		{"* TODO Task\nSCHEDULED: <2026-01-05 Mon>", "DEADLINE", "<2026-01-09 Fri>", "* TODO Task\nSCHEDULED: <2026-01-05 Mon> DEADLINE: <2026-01-09 Fri>"},
+		{"* TODO Task\nDEADLINE: <2026-01-09 Fri> SCHEDULED: <2026-01-05 Mon>", "SCHEDULED", "<2026-01-06 Tue 10:00>", "* TODO Task\nDEADLINE: <2026-01-09 Fri> SCHEDULED: <2026-01-06 Tue 10:00>"},
+		{"* TODO Task\nSCHEDULED: <2026-01-05 Mon> DEADLINE: <2026-01-09 Fri>", "SCHEDULED", "", "* TODO Task\nDEADLINE: <2026-01-09 Fri>"},
+		{"* TODO Task\nSCHEDULED: <2026-01-05 Mon>\nBody", "SCHEDULED", "", "* TODO Task\nBody"},
+		{"* TODO Task\nBody", "DEADLINE", "", "* TODO Task\nBody"},
+	}
+	for _, tt := range tests {
+		lines := SetPlanning(split(tt.input), 0, tt.key, tt.ts)
+		if got := strings.Join(lines, "\n"); got != tt.expected {
+			t.Errorf("SetPlanning(%q, %s, %q) = %q, want %q", tt.input, tt.key, tt.ts, got, tt.expected)
+		}
+	}
+
+	lines := split("* TODO Task\nCLOSED: [2026-01-05 Mon 10:00] SCHEDULED: <2026-01-05 Mon +1w>")
+	if got := PlanningTimestamp(lines, 0, "SCHEDULED"); got != "<2026-01-05 Mon +1w>" {
+		t.Errorf("PlanningTimestamp(SCHEDULED) = %q", got)
+	}
+	if got := PlanningTimestamp(lines, 0, "DEADLINE"); got != "" {
+		t.Errorf("PlanningTimestamp(DEADLINE) = %q", got)
+	}
+}
+
+func TestSetPriority(t *testing.T) {
+	tests := []struct {
+		line, keyword, priority string
+		expected                string
+	}{
+		{"* TODO Task", "TODO", "A", "* TODO [#A] Task"},
+		{"** TODO [#A] Task :tag:", "TODO", "C", "** TODO [#C] Task :tag:"},
+		{"* TODO [#B] Task", "TODO", "", "* TODO Task"},
+		{"* Task", "", "A", "* [#A] Task"},
+		{"* [#A] Task", "", "", "* Task"},
+		{"* TODO", "TODO", "B", "* TODO [#B]"},
+	}
+	for _, tt := range tests {
+		if got := SetPriority(tt.line, tt.keyword, tt.priority); got != tt.expected {
+			t.Errorf("SetPriority(%q, %q, %q) = %q, want %q", tt.line, tt.keyword, tt.priority, got, tt.expected)
+		}
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	tests := []struct {
+		line     string
+		tags     []string
+		column   int
+		expected string
+	}{
+		{"* TODO Task", []string{"work"}, 0, "* TODO Task :work:"},
+		{"* TODO Task :old:", []string{"a", "b"}, 0, "* TODO Task :a:b:"},
+		{"* TODO Task    :old:", nil, 0, "* TODO Task"},
+		{"* TODO Task", []string{"work"}, 20, "* TODO Task         :work:"},
+		{"* TODO Task", []string{"work"}, -30, "* TODO Task" + strings.Repeat(" ", 13) + ":work:"},
+		{"* TODO A rather long headline title", []string{"work"}, -30, "* TODO A rather long headline title :work:"},
+	}
+	for _, tt := range tests {
+		if got := SetTags(tt.line, tt.tags, tt.column); got != tt.expected {
+			t.Errorf("SetTags(%q, %v, %d) = %q, want %q", tt.line, tt.tags, tt.column, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package edit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromoteSubtree decreases the level of the headline at idx and of all its
+// descendants by one, like org-promote-subtree.
+func PromoteSubtree(lines []string, idx int) ([]string, error) {
+	if HeadlineLevel(lines[idx]) <= 1 {
+		return nil, fmt.Errorf("cannot promote a top-level headline")
+	}
+	return shiftSubtree(lines, idx, -1), nil
+}
+
+// DemoteSubtree increases the level of the headline at idx and of all its
+// descendants by one, like org-demote-subtree.
+func DemoteSubtree(lines []string, idx int) []string {
+	return shiftSubtree(lines, idx, 1)
+}
+
+// MoveSubtreeUp swaps the subtree at idx with the previous sibling subtree
+// and returns the lines together with the new index of the headline.
+func MoveSubtreeUp(lines []string, idx int) ([]string, int, error) {
+	level := HeadlineLevel(lines[idx])
+	prev := -1
+	for i := idx - 1; i >= 0; i-- {
+		if l := HeadlineLevel(lines[i]); l > 0 && l <= level {
+			if l == level {
+				prev = i
+			}
+			break
+		}
+	}
+	if prev == -1 {
+		return nil, 0, fmt.Errorf("cannot move past the first sibling")
+	}
+	return swapBlocks(lines, prev, idx, subtreeBlockEnd(lines, idx)), prev, nil
+}
+
+// MoveSubtreeDown swaps the subtree at idx with the next sibling subtree and
+// returns the lines together with the new index of the headline.
+func MoveSubtreeDown(lines []string, idx int) ([]string, int, error) {
+	level := HeadlineLevel(lines[idx])
+	next := subtreeBlockEnd(lines, idx)
+	if next >= len(lines) || HeadlineLevel(lines[next]) != level {
+		return nil, 0, fmt.Errorf("cannot move past the last sibling")
+	}
+	end := subtreeBlockEnd(lines, next)
+	return swapBlocks(lines, idx, next, end), idx + end - next, nil
+}
+
+// DeleteSubtree removes the headline at idx with its body and descendants.
+func DeleteSubtree(lines []string, idx int) []string {
+	end := subtreeBlockEnd(lines, idx)
+	res := make([]string, 0, len(lines)-(end-idx))
+	res = append(res, lines[:idx]...)
+	return append(res, lines[end:]...)
+}
+
+// subtreeBlockEnd is SubtreeEnd without the empty element a trailing newline
+// leaves at the end of lines, so that moving the last subtree of a file
+// keeps the file ending in a newline.
+func subtreeBlockEnd(lines []string, idx int) int {
+	end := SubtreeEnd(lines, idx)
+	if end == len(lines) && end > idx+1 && lines[end-1] == "" {
+		end--
+	}
+	return end
+}
+
+// swapBlocks exchanges the adjacent blocks lines[a:b] and lines[b:c].
+func swapBlocks(lines []string, a, b, c int) []string {
+	res := make([]string, 0, len(lines))
+	res = append(res, lines[:a]...)
+	res = append(res, lines[b:c]...)
+	res = append(res, lines[a:b]...)
+	return append(res, lines[c:]...)
+}
+
+func shiftSubtree(lines []string, idx, delta int) []string {
+	res := append([]string(nil), lines...)
+	end := SubtreeEnd(lines, idx)
+	for i := idx; i < end; i++ {
+		if level := HeadlineLevel(lines[i]); level > 0 {
+			res[i] = strings.Repeat("*", level+delta) + lines[i][level:]
+		}
+	}
+	return res
+}
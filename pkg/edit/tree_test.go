@@ -0,0 +1,84 @@
+package edit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const treeFixture = `* A
+** A1
+body
+*** A1a
+** A2
+* B
+`
+
+func TestPromoteDemoteSubtree(t *testing.T) {
+	lines := strings.Split(treeFixture, "\n")
+
+	got, err := PromoteSubtree(lines, 1)
+	if err != nil {
+		t.Fatalf("PromoteSubtree failed: %v", err)
+	}
+	want := "* A\n* A1\nbody\n** A1a\n** A2\n* B\n"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("PromoteSubtree() = %q, want %q", strings.Join(got, "\n"), want)
+	}
+	if _, err := PromoteSubtree(lines, 0); err == nil {
+		t.Error("Expected an error when promoting a top-level headline")
+	}
+
+	got = DemoteSubtree(lines, 0)
+	want = "** A\n*** A1\nbody\n**** A1a\n*** A2\n* B\n"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("DemoteSubtree() = %q, want %q", strings.Join(got, "\n"), want)
+	}
+}
+
+func TestMoveSubtree(t *testing.T) {
+	lines := strings.Split(treeFixture, "\n")
+
+	got, idx, err := MoveSubtreeDown(lines, 1)
+	if err != nil {
+		t.Fatalf("MoveSubtreeDown failed: %v", err)
+	}
+	want := "* A\n** A2\n** A1\nbody\n*** A1a\n* B\n"
+	if strings.Join(got, "\n") != want || idx != 2 {
+		t.Errorf("MoveSubtreeDown() = %q, %d", strings.Join(got, "\n"), idx)
+	}
+
+	back, idx, err := MoveSubtreeUp(got, 2)
+	if err != nil || idx != 1 || !reflect.DeepEqual(back, lines) {
+		t.Errorf("MoveSubtreeUp() = %q, %d, %v", strings.Join(back, "\n"), idx, err)
+	}
+
+	// The last subtree of the file keeps the trailing newline in place.
+	got, idx, err = MoveSubtreeDown(lines, 0)
+	if err != nil {
+		t.Fatalf("MoveSubtreeDown failed: %v", err)
+	}
+	want = "* B\n* A\n** A1\nbody\n*** A1a\n** A2\n"
+	if strings.Join(got, "\n") != want || idx != 1 {
+		t.Errorf("MoveSubtreeDown() = %q, %d", strings.Join(got, "\n"), idx)
+	}
+
+	if _, _, err := MoveSubtreeUp(lines, 1); err == nil {
+		t.Error("Expected an error when moving the first child up")
+	}
+	if _, _, err := MoveSubtreeDown(lines, 4); err == nil {
+		t.Error("Expected an error when moving the last child down")
+	}
+}
+
+func TestDeleteSubtree(t *testing.T) {
+	lines := strings.Split(treeFixture, "\n")
+	got := strings.Join(DeleteSubtree(lines, 1), "\n")
+	if got != "* A\n** A2\n* B\n" {
+		t.Errorf("DeleteSubtree() = %q", got)
+	}
+	got = strings.Join(DeleteSubtree(lines, 5), "\n")
+	if got != "* A\n** A1\nbody\n*** A1a\n** A2\n" {
+		t.Errorf("DeleteSubtree() of the last subtree = %q", got)
+	}
+}
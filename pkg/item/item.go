@@ -8,16 +8,133 @@ const (
 	StatusWaiting = "WAITING"
 )
 
+// PriorityRange describes the priority scale of a file, mirroring Org's
+// org-priority-highest, org-priority-lowest and org-priority-default.
+type PriorityRange struct {
+	Highest string `json:"highest"`
+	Lowest  string `json:"lowest"`
+	Default string `json:"default"`
+}
+
+// DefaultPriorityRange is Org's built-in scale: A is highest, C is lowest and
+// headlines without a cookie are treated as B.
+var DefaultPriorityRange = PriorityRange{Highest: "A", Lowest: "C", Default: "B"}
+
+// Contains reports whether p lies within the range. Org compares priorities by
+// character code, so "1" to "9" and "A" to "E" both work as scales.
+func (r PriorityRange) Contains(p string) bool {
+	if len(p) != 1 || len(r.Highest) != 1 || len(r.Lowest) != 1 {
+		return false
+	}
+	return p[0] >= r.Highest[0] && p[0] <= r.Lowest[0]
+}
+
+// Valid reports whether the range is well formed.
+func (r PriorityRange) Valid() bool {
+	if len(r.Highest) != 1 || len(r.Lowest) != 1 || len(r.Default) != 1 {
+		return false
+	}
+	return r.Highest[0] <= r.Lowest[0] && r.Contains(r.Default)
+}
+
+// Logging requested by a TODO keyword, written as "!" (timestamp) or "@"
+// (timestamp and note) in org-todo-keywords.
+const (
+	LogNone = ""
+	LogTime = "time"
+	LogNote = "note"
+)
+
+// Keyword is a TODO keyword as declared in org-todo-keywords or #+TODO. A
+// declaration such as "WAIT(w@/!)" has the fast-access key "w", records a note
+// when a task enters the state (Enter) and a timestamp when it leaves it
+// (Leave). Done is set for the keywords after the "|" of a sequence.
+type Keyword struct {
+	Name  string `json:"name"`
+	Key   string `json:"key,omitempty"`
+	Done  bool   `json:"done"`
+	Enter string `json:"enter,omitempty"`
+	Leave string `json:"leave,omitempty"`
+}
+
+// Keywords lists the TODO keywords of a file in declaration order.
+type Keywords []Keyword
+
+// DefaultKeywords are the keywords recognized when neither the configuration
+// nor the file declares any.
+var DefaultKeywords = Keywords{
+	{Name: StatusTodo},
+	{Name: StatusWaiting},
+	{Name: StatusDone, Done: true},
+}
+
+// Find returns the keyword called name, falling back to the keyword whose
+// fast-access key is name.
+func (k Keywords) Find(name string) (Keyword, bool) {
+	for _, kw := range k {
+		if kw.Name == name {
+			return kw, true
+		}
+	}
+	for _, kw := range k {
+		if kw.Key != "" && kw.Key == name {
+			return kw, true
+		}
+	}
+	return Keyword{}, false
+}
+
+// IsDone reports whether name is a done keyword.
+func (k Keywords) IsDone(name string) bool {
+	kw, ok := k.Find(name)
+	return ok && kw.Done
+}
+
+// NextDone returns the done keyword that completes a task in state name: the
+// first done keyword declared after it, which belongs to the same sequence.
+func (k Keywords) NextDone(name string) (Keyword, bool) {
+	found := false
+	for _, kw := range k {
+		if kw.Name == name {
+			found = true
+		}
+		if found && kw.Done {
+			return kw, true
+		}
+	}
+	return Keyword{}, false
+}
+
 // Item represents an entry in an Org file.
+// Done is set when Status is one of the file's done keywords.
+// EffectivePriority is Priority, or the file's default priority when the
+// headline carries no priority cookie, and Priorities the scale of the file.
+// Effort is the raw :EFFORT: property and EffortMinutes its parsed value. ID
+// and CustomID come from the :ID: and :CUSTOM_ID: properties and identify the
+// item independently of its position.
+// Fingerprint captures the headline as listed so that later edits can detect
+// that the file changed underneath them. BlockedBy references the open tasks
+// that have to be completed before this one.
 type Item struct {
-	Title      string     `json:"title"`
-	Level      int        `json:"level"`
-	Status     string     `json:"status"`
-	Priority   string     `json:"priority,omitempty"`
-	Tags       []string   `json:"tags,omitempty"`
-	Scheduled  *time.Time `json:"scheduled,omitempty"`
-	Deadline   *time.Time `json:"deadline,omitempty"`
-	FilePath   string     `json:"filePath"`
-	LineNumber int        `json:"lineNumber"`
-	RawContent string     `json:"rawContent,omitempty"`
+	ID                string            `json:"id,omitempty"`
+	CustomID          string            `json:"customId,omitempty"`
+	Title             string            `json:"title"`
+	Level             int               `json:"level"`
+	Status            string            `json:"status"`
+	Done              bool              `json:"done,omitempty"`
+	Priority          string            `json:"priority,omitempty"`
+	EffectivePriority string            `json:"effectivePriority,omitempty"`
+	Priorities        PriorityRange     `json:"-"`
+	Tags              []string          `json:"tags,omitempty"`
+	Scheduled         *time.Time        `json:"scheduled,omitempty"`
+	Deadline          *time.Time        `json:"deadline,omitempty"`
+	Closed            *time.Time        `json:"closed,omitempty"`
+	FilePath          string            `json:"filePath"`
+	LineNumber        int               `json:"lineNumber"`
+	Fingerprint       string            `json:"fingerprint,omitempty"`
+	RawContent        string            `json:"rawContent,omitempty"`
+	Properties        map[string]string `json:"properties,omitempty"`
+	Effort            string            `json:"effort,omitempty"`
+	EffortMinutes     int               `json:"effortMinutes,omitempty"`
+	BlockedBy         []string          `json:"blockedBy,omitempty"`
 }
@@ -0,0 +1,209 @@
+// Package journal records the changes made to Org files so that they can be
+// undone. Each entry keeps, per file, the hashes of the content before and
+// after the change and the diff between them; an entry is only reverted
+// while the file still matches the recorded result.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/diff"
+	"github.com/garaemon/org-agenda-cli/pkg/orgfile"
+)
+
+// MaxEntries bounds the number of entries kept; older ones are dropped.
+var MaxEntries = 100
+
+// Change is the modification of one file. Created is set when the file did
+// not exist before, in which case undoing the change removes it.
+type Change struct {
+	File    string      `json:"file"`
+	Before  string      `json:"before"`
+	After   string      `json:"after"`
+	Created bool        `json:"created,omitempty"`
+	Hunks   []diff.Hunk `json:"hunks"`
+}
+
+// Entry groups the changes made by one operation, described by Op.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Changes []Change  `json:"changes"`
+}
+
+// Files returns the files touched by e.
+func (e Entry) Files() []string {
+	files := make([]string, len(e.Changes))
+	for i, c := range e.Changes {
+		files[i] = c.File
+	}
+	return files
+}
+
+// Journal is a journal stored as JSON lines at a path.
+type Journal struct {
+	path string
+}
+
+// Open returns the journal stored at path. The file is created on the first
+// Append.
+func Open(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// DefaultPath returns the location of the journal under the XDG state
+// directory ($XDG_STATE_HOME, or ~/.local/state).
+func DefaultPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "org-agenda-cli", "journal.jsonl")
+}
+
+// Hash returns the hash recorded for content.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewChange describes the change of file from before to after. A nil before
+// means the file was created.
+func NewChange(file string, before, after []byte) Change {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	return Change{
+		File:    file,
+		Before:  Hash(before),
+		After:   Hash(after),
+		Created: before == nil,
+		Hunks:   diff.Compute(splitLines(before), splitLines(after)),
+	}
+}
+
+// Revert returns the content c.File had before c, given its current content.
+// It fails when the file diverged since the change was recorded.
+func (c Change) Revert(current []byte) ([]byte, error) {
+	if Hash(current) != c.After {
+		return nil, fmt.Errorf("%s has been modified since", c.File)
+	}
+	lines, err := diff.Apply(splitLines(current), diff.Reverse(c.Hunks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to revert %s: %w", c.File, err)
+	}
+	reverted := []byte(strings.Join(lines, "\n"))
+	if Hash(reverted) != c.Before {
+		return nil, fmt.Errorf("failed to revert %s: the result does not match the recorded content", c.File)
+	}
+	return reverted, nil
+}
+
+// Append adds e to the journal.
+func (j *Journal) Append(e Entry) error {
+	unlock, err := orgfile.Lock(j.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := j.read()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, e)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	return j.write(entries)
+}
+
+// Entries returns the recorded entries, oldest first.
+func (j *Journal) Entries() ([]Entry, error) {
+	return j.read()
+}
+
+// Undo passes the newest steps entries, newest first, to revert and drops
+// each one revert succeeds for. It stops at the first failure and returns the
+// entries undone until then along with the error.
+func (j *Journal) Undo(steps int, revert func(Entry) error) ([]Entry, error) {
+	unlock, err := orgfile.Lock(j.path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+	var undone []Entry
+	for ; steps > 0 && len(entries) > 0; steps-- {
+		e := entries[len(entries)-1]
+		if err = revert(e); err != nil {
+			break
+		}
+		undone = append(undone, e)
+		entries = entries[:len(entries)-1]
+	}
+	if len(undone) > 0 {
+		if werr := j.write(entries); werr != nil {
+			return undone, werr
+		}
+	}
+	return undone, err
+}
+
+func (j *Journal) read() ([]Entry, error) {
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("corrupt journal %s: %w", j.path, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+func (j *Journal) write(entries []Entry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return orgfile.WriteFile(j.path, buf.Bytes())
+}
+
+func splitLines(content []byte) []string {
+	return strings.Split(string(content), "\n")
+}
@@ -0,0 +1,47 @@
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalAppendAndUndo(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	j := Open(filepath.Join(t.TempDir(), "journal.jsonl"))
+	MaxEntries = 2
+	defer func() { MaxEntries = 100 }()
+
+	for _, op := range []string{"first", "second", "third"} {
+		if err := j.Append(Entry{Time: time.Now(), Op: op}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	entries, err := j.Entries()
+	if err != nil || len(entries) != 2 || entries[0].Op != "second" {
+		t.Fatalf("Expected the last 2 entries, got %+v (%v)", entries, err)
+	}
+
+	var reverted []string
+	undone, err := j.Undo(5, func(e Entry) error {
+		reverted = append(reverted, e.Op)
+		return nil
+	})
+	if err != nil || len(undone) != 2 || reverted[0] != "third" {
+		t.Errorf("Unexpected undo: %v, %v", reverted, err)
+	}
+}
+
+func TestChangeRevert(t *testing.T) {
+	before := []byte("* TODO Task\n")
+	after := []byte("* DONE Task\nCLOSED: [2026-01-05 Mon 10:00]\n")
+	c := NewChange("tasks.org", before, after)
+
+	got, err := c.Revert(after)
+	if err != nil || string(got) != string(before) {
+		t.Errorf("Revert() = %q, %v", got, err)
+	}
+	if _, err := c.Revert([]byte("* DONE Task\n")); err == nil {
+		t.Error("Expected an error for diverged content")
+	}
+}
@@ -2,10 +2,14 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/garaemon/org-agenda-cli/pkg/agenda"
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
+	"github.com/garaemon/org-agenda-cli/pkg/dateinput"
 	"github.com/garaemon/org-agenda-cli/pkg/service"
 	"github.com/garaemon/org-agenda-cli/pkg/version"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -32,13 +36,25 @@ func (s *Server) Start() error {
 
 func (s *Server) registerTools() {
 	s.server.AddTool(mcp.NewTool("list_todos",
-		mcp.WithDescription("List TODO items with optional filtering"),
+		mcp.WithDescription("List TODO items with optional filtering. Blocked tasks carry a blockedBy list with the references of the open tasks blocking them"),
 		mcp.WithString("status",
 			mcp.Description("Filter by status (TODO, DONE, WAITING)"),
 		),
 		mcp.WithString("tag",
 			mcp.Description("Filter by tag"),
 		),
+		mcp.WithString("effort",
+			mcp.Description("Filter by effort, e.g. <1:00 or >=2h"),
+		),
+		mcp.WithString("sort",
+			mcp.Description("Comma-separated sort keys, prefix with '-' to reverse (priority, date, scheduled, deadline, title, status, file). Defaults to priority,date"),
+		),
+		mcp.WithBoolean("blocked",
+			mcp.Description("Only list tasks blocked by open children, ordered siblings or :BLOCKER: tasks"),
+		),
+		mcp.WithBoolean("actionable",
+			mcp.Description("Only list tasks that are not blocked"),
+		),
 	), s.handleListTodos)
 
 	s.server.AddTool(mcp.NewTool("add_todo",
@@ -57,29 +73,236 @@ func (s *Server) registerTools() {
 			mcp.Description("Comma-separated tags"),
 		),
 		mcp.WithString("schedule",
-			mcp.Description("Scheduled date (YYYY-MM-DD or <YYYY-MM-DD ...>)"),
+			mcp.Description("Scheduled date: YYYY-MM-DD, an Org timestamp or relative input such as tomorrow, +3, fri, 2/14 or '+2d 15:00'"),
 		),
 		mcp.WithString("deadline",
-			mcp.Description("Deadline date (YYYY-MM-DD or <YYYY-MM-DD ...>)"),
+			mcp.Description("Deadline date: YYYY-MM-DD, an Org timestamp or relative input such as tomorrow, +3, fri, 2/14 or '+2d 15:00'"),
 		),
 	), s.handleAddTodo)
 
+	s.server.AddTool(mcp.NewTool("capture",
+		mcp.WithDescription("Capture content with one of the configured capture templates, like org-capture"),
+		mcp.WithString("content",
+			mcp.Description("Content inserted for the %c placeholder of the template"),
+			mcp.Required(),
+		),
+		mcp.WithString("template",
+			mcp.Description("Key of the capture template; may be omitted when only one is configured"),
+		),
+		mcp.WithString("initial",
+			mcp.Description("Initial content inserted for the %i placeholder, indented to line up"),
+		),
+		mcp.WithString("link",
+			mcp.Description("Link inserted for the %a placeholder, e.g. file:/path/to/file.go::42"),
+		),
+		mcp.WithString("under",
+			mcp.Description("The :ID: or :CUSTOM_ID: of a headline in any configured file to capture below instead of the template's target; entries become children and other types notes on it"),
+		),
+		mcp.WithObject("vars",
+			mcp.Description("Answers to the prompts of the template keyed by prompt name, e.g. {\"Project\": \"website\", \"Tags\": \"work\", \"Date\": \"fri\"}; prompts without an answer take their default"),
+		),
+	), s.handleCapture)
+
 	s.server.AddTool(mcp.NewTool("mark_done",
 		mcp.WithDescription("Mark a task as DONE"),
 		mcp.WithString("id",
-			mcp.Description("Task ID (currently file path:line number, e.g., /path/to/file.org:10)"),
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
 			mcp.Required(),
 		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Complete the task even if it is blocked by open tasks"),
+		),
 	), s.handleMarkDone)
 
+	s.server.AddTool(mcp.NewTool("set_state",
+		mcp.WithDescription("Move a task to another TODO keyword, setting or clearing CLOSED and logging the change as configured"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("state",
+			mcp.Description("Target TODO keyword (e.g., TODO, WAIT, DONE) or its fast-access key"),
+			mcp.Required(),
+		),
+		mcp.WithString("note",
+			mcp.Description("Note to log with the state change"),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Complete the task even if it is blocked by open tasks"),
+		),
+	), s.handleSetState)
+
+	s.server.AddTool(mcp.NewTool("add_note",
+		mcp.WithDescription("Add a timestamped \"Note taken on\" entry to a task, in its LOGBOOK drawer or at the end of its body as configured"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("note",
+			mcp.Description("Text of the note; may span several lines"),
+			mcp.Required(),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+	), s.handleAddNote)
+
+	s.server.AddTool(mcp.NewTool("schedule_task",
+		mcp.WithDescription("Set or remove the SCHEDULED date of a task"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("date",
+			mcp.Description("New date: YYYY-MM-DD or relative Org-style input such as +2d, fri, next mon or '+1w 10:00'"),
+		),
+		mcp.WithBoolean("remove",
+			mcp.Description("Remove the date instead of setting it"),
+		),
+		mcp.WithString("note",
+			mcp.Description("Note to log with the change"),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+	), s.handleScheduleTask)
+
+	s.server.AddTool(mcp.NewTool("set_deadline",
+		mcp.WithDescription("Set or remove the DEADLINE date of a task"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("date",
+			mcp.Description("New date: YYYY-MM-DD or relative Org-style input such as +2d, fri, next mon or '+1w 10:00'"),
+		),
+		mcp.WithBoolean("remove",
+			mcp.Description("Remove the date instead of setting it"),
+		),
+		mcp.WithString("note",
+			mcp.Description("Note to log with the change"),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+	), s.handleSetDeadline)
+
+	s.server.AddTool(mcp.NewTool("set_priority",
+		mcp.WithDescription("Change the priority cookie of a task"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("priority",
+			mcp.Description("New priority within the file's range (e.g., A, B, C), 'none' to remove it, or 'up'/'down' to move one step"),
+			mcp.Required(),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+	), s.handleSetPriority)
+
+	s.server.AddTool(mcp.NewTool("edit_tags",
+		mcp.WithDescription("Add, remove or replace the tags of a task"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("add",
+			mcp.Description("Comma-separated tags to add"),
+		),
+		mcp.WithString("remove",
+			mcp.Description("Comma-separated tags to remove"),
+		),
+		mcp.WithString("set",
+			mcp.Description("Comma-separated tags replacing all existing tags (applied before add/remove); an empty string clears them"),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+	), s.handleEditTags)
+
+	s.server.AddTool(mcp.NewTool("refile",
+		mcp.WithDescription("Move a task with its body and children below another headline, possibly in another file"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("file",
+			mcp.Description("Target file (defaults to the task's own file)"),
+		),
+		mcp.WithString("olp",
+			mcp.Description("Slash-separated outline path of the new parent headline, e.g., Projects/Website. Omit to move the subtree to the top level"),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+	), s.handleRefile)
+
+	s.server.AddTool(mcp.NewTool("edit_tree",
+		mcp.WithDescription("Edit the outline structure around a task; every action applies to the whole subtree"),
+		mcp.WithString("id",
+			mcp.Description("Task ID: the :ID: or :CUSTOM_ID: property value (preferred, stable across edits) or file path:line number, e.g., /path/to/file.org:10"),
+			mcp.Required(),
+		),
+		mcp.WithString("action",
+			mcp.Description("promote or demote by one level, move-up or move-down past a sibling, or delete"),
+			mcp.Enum("promote", "demote", "move-up", "move-down", "delete"),
+			mcp.Required(),
+		),
+		mcp.WithString("fingerprint",
+			mcp.Description("Fingerprint of the task as returned by list_todos; the edit is rejected if the headline changed since"),
+		),
+		mcp.WithBoolean("relocate",
+			mcp.Description("When the fingerprint no longer matches the given position, look the task up by fingerprint in the same file"),
+		),
+	), s.handleEditTree)
+
+	s.server.AddTool(mcp.NewTool("list_refile_targets",
+		mcp.WithDescription("List the files and headlines tasks can be refiled to"),
+	), s.handleListRefileTargets)
+
 	s.server.AddTool(mcp.NewTool("get_agenda",
 		mcp.WithDescription("Get agenda items for a specific date range"),
 		mcp.WithString("date",
-			mcp.Description("Reference date (YYYY-MM-DD)"),
+			mcp.Description("Reference date: YYYY-MM-DD or relative input such as today, +1w or mon"),
 		),
 		mcp.WithString("range",
 			mcp.Description("Range type (day, week, month)"),
 		),
+		mcp.WithString("sort",
+			mcp.Description("Comma-separated sort keys, prefix with '-' to reverse (priority, date, scheduled, deadline, title, status, file). Defaults to priority,date"),
+		),
 	), s.handleGetAgenda)
 }
 
@@ -92,12 +315,29 @@ func (s *Server) handleListTodos(ctx context.Context, request mcp.CallToolReques
 
 	status, _ := args["status"].(string)
 	tag, _ := args["tag"].(string)
+	effort, _ := args["effort"].(string)
+	sortStr, _ := args["sort"].(string)
+	blocked, _ := args["blocked"].(bool)
+	actionable, _ := args["actionable"].(bool)
+	if blocked && actionable {
+		return mcp.NewToolResultError("blocked and actionable are mutually exclusive"), nil
+	}
+
+	sortKeys, err := agenda.ParseSortKeys(sortStr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid sort: %v", err)), nil
+	}
 
 	items, err := s.svc.ListTodos(service.ListOptions{
-		Status: status,
-		Tag:    tag,
+		Status:     status,
+		Tag:        tag,
+		Effort:     effort,
+		Sort:       sortKeys,
+		Blocked:    blocked,
+		Actionable: actionable,
 	})
-	if err != nil {
+	// Unreadable files are skipped, as in the agenda.
+	if err != nil && !errors.As(err, new(service.ReadErrors)) {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to list todos: %v", err)), nil
 	}
 
@@ -122,15 +362,7 @@ func (s *Server) handleAddTodo(ctx context.Context, request mcp.CallToolRequest)
 	schedule, _ := args["schedule"].(string)
 	deadline, _ := args["deadline"].(string)
 
-	var tags []string
-	if tagsStr != "" {
-		for _, t := range strings.Split(tagsStr, ",") {
-			t = strings.TrimSpace(t)
-			if t != "" {
-				tags = append(tags, t)
-			}
-		}
-	}
+	tags := splitList(tagsStr)
 
 	err := s.svc.AddTodo(title, service.AddOptions{
 		File:     file,
@@ -146,6 +378,55 @@ func (s *Server) handleAddTodo(ctx context.Context, request mcp.CallToolRequest)
 	return mcp.NewToolResultText("Todo added successfully"), nil
 }
 
+func (s *Server) handleCapture(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	content, ok := args["content"].(string)
+	if !ok {
+		return mcp.NewToolResultError("Content is required"), nil
+	}
+	key, _ := args["template"].(string)
+	answers := capture.Answers{}
+	if vars, ok := args["vars"].(map[string]interface{}); ok {
+		for name, value := range vars {
+			answers[name] = fmt.Sprint(value)
+		}
+	}
+
+	tmpl, err := s.svc.CaptureTemplate(key)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to capture: %v", err)), nil
+	}
+	if under, _ := args["under"].(string); under != "" {
+		tmpl.Target, tmpl.ID, tmpl.File = capture.TargetID, under, ""
+	}
+	at := time.Now()
+	if tmpl, err = s.svc.ResolveCaptureTarget(tmpl, at); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to capture: %v", err)), nil
+	}
+	in := s.svc.CaptureInput(content, at)
+	in.Initial, _ = args["initial"].(string)
+	in.Link, _ = args["link"].(string)
+	in.Prompter = answers
+	entry, warnings, err := tmpl.Entry(in)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to capture: %v", err)), nil
+	}
+	file, err := s.svc.Capture(tmpl, entry, at)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to capture: %v", err)), nil
+	}
+
+	text := fmt.Sprintf("Captured to %s", file)
+	for _, w := range warnings {
+		text += "\nWarning: " + w
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
 func (s *Server) handleMarkDone(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, ok := request.Params.Arguments.(map[string]interface{})
 	if !ok {
@@ -157,7 +438,15 @@ func (s *Server) handleMarkDone(ctx context.Context, request mcp.CallToolRequest
 		return mcp.NewToolResultError("ID is required"), nil
 	}
 
-	err := s.svc.MarkDone(id)
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+	force, _ := args["force"].(bool)
+
+	err := s.svc.MarkDone(id, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+		Force:       force,
+	})
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to mark done: %v", err)), nil
 	}
@@ -165,6 +454,291 @@ func (s *Server) handleMarkDone(ctx context.Context, request mcp.CallToolRequest
 	return mcp.NewToolResultText("Task marked as DONE"), nil
 }
 
+func (s *Server) handleSetState(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return mcp.NewToolResultError("ID is required"), nil
+	}
+	state, ok := args["state"].(string)
+	if !ok {
+		return mcp.NewToolResultError("State is required"), nil
+	}
+
+	note, _ := args["note"].(string)
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+	force, _ := args["force"].(bool)
+
+	name, err := s.svc.SetState(id, state, note, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+		Force:       force,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to set state: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Task state set to %s", name)), nil
+}
+
+func (s *Server) handleAddNote(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return mcp.NewToolResultError("ID is required"), nil
+	}
+	note, ok := args["note"].(string)
+	if !ok {
+		return mcp.NewToolResultError("Note is required"), nil
+	}
+
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+
+	err := s.svc.AddNote(id, note, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add note: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText("Note added"), nil
+}
+
+func (s *Server) handleScheduleTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handlePlanning(request, "SCHEDULED", s.svc.Reschedule)
+}
+
+func (s *Server) handleSetDeadline(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handlePlanning(request, "DEADLINE", s.svc.SetDeadline)
+}
+
+// handlePlanning implements schedule_task and set_deadline with set, the
+// service method changing the date of key.
+func (s *Server) handlePlanning(request mcp.CallToolRequest, key string, set func(ref, input, note string, opts service.EditOptions) (string, error)) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return mcp.NewToolResultError("ID is required"), nil
+	}
+
+	date, _ := args["date"].(string)
+	remove, _ := args["remove"].(bool)
+	note, _ := args["note"].(string)
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+
+	if (strings.TrimSpace(date) == "") == !remove {
+		return mcp.NewToolResultError("Specify either a date or remove=true"), nil
+	}
+
+	ts, err := set(id, date, note, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to update %s: %v", key, err)), nil
+	}
+
+	if ts == "" {
+		return mcp.NewToolResultText(fmt.Sprintf("%s removed", key)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s set to %s", key, ts)), nil
+}
+
+func (s *Server) handleSetPriority(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return mcp.NewToolResultError("ID is required"), nil
+	}
+	priority, ok := args["priority"].(string)
+	if !ok {
+		return mcp.NewToolResultError("Priority is required"), nil
+	}
+
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+
+	next, err := s.svc.SetPriority(id, priority, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to set priority: %v", err)), nil
+	}
+
+	if next == "" {
+		return mcp.NewToolResultText("Priority removed"), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Priority set to %s", next)), nil
+}
+
+func (s *Server) handleEditTags(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return mcp.NewToolResultError("ID is required"), nil
+	}
+
+	add, _ := args["add"].(string)
+	remove, _ := args["remove"].(string)
+	set, replace := args["set"].(string)
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+
+	change := service.TagChange{
+		Replace: replace,
+		Set:     splitList(set),
+		Add:     splitList(add),
+		Remove:  splitList(remove),
+	}
+	if !change.Replace && len(change.Add) == 0 && len(change.Remove) == 0 {
+		return mcp.NewToolResultError("Specify add, remove or set"), nil
+	}
+
+	tags, err := s.svc.EditTags(id, change, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to edit tags: %v", err)), nil
+	}
+
+	return mcp.NewToolResultJSON(map[string]interface{}{
+		"tags": tags,
+	})
+}
+
+func (s *Server) handleRefile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return mcp.NewToolResultError("ID is required"), nil
+	}
+
+	file, _ := args["file"].(string)
+	olp, _ := args["olp"].(string)
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+
+	var titles []string
+	for _, title := range strings.Split(olp, "/") {
+		if title = strings.TrimSpace(title); title != "" {
+			titles = append(titles, title)
+		}
+	}
+
+	moved, err := s.svc.Refile(id, service.RefileTarget{File: file, OLP: titles}, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to refile task: %v", err)), nil
+	}
+
+	return mcp.NewToolResultJSON(map[string]interface{}{
+		"file": moved.FilePath,
+		"line": moved.Line,
+	})
+}
+
+func (s *Server) handleEditTree(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("Invalid arguments"), nil
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return mcp.NewToolResultError("ID is required"), nil
+	}
+	action, _ := args["action"].(string)
+	fingerprint, _ := args["fingerprint"].(string)
+	relocate, _ := args["relocate"].(bool)
+
+	var apply func(string, service.EditOptions) (*service.Target, error)
+	switch action {
+	case "promote":
+		apply = s.svc.PromoteSubtree
+	case "demote":
+		apply = s.svc.DemoteSubtree
+	case "move-up":
+		apply = s.svc.MoveSubtreeUp
+	case "move-down":
+		apply = s.svc.MoveSubtreeDown
+	case "delete":
+		apply = s.svc.DeleteSubtree
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Unknown action %q", action)), nil
+	}
+
+	t, err := apply(id, service.EditOptions{
+		Fingerprint: fingerprint,
+		Relocate:    relocate,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to %s subtree: %v", action, err)), nil
+	}
+
+	if action == "delete" {
+		return mcp.NewToolResultText("Subtree deleted"), nil
+	}
+	return mcp.NewToolResultJSON(map[string]interface{}{
+		"file": t.FilePath,
+		"line": t.Line,
+	})
+}
+
+func (s *Server) handleListRefileTargets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	targets, err := s.svc.ListRefileTargets()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list refile targets: %v", err)), nil
+	}
+
+	return mcp.NewToolResultJSON(map[string]interface{}{
+		"targets": targets,
+	})
+}
+
+// splitList splits a comma-separated argument, dropping empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
 func (s *Server) handleGetAgenda(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args, ok := request.Params.Arguments.(map[string]interface{})
 	if !ok {
@@ -173,14 +747,20 @@ func (s *Server) handleGetAgenda(ctx context.Context, request mcp.CallToolReques
 
 	dateStr, _ := args["date"].(string)
 	rangeType, _ := args["range"].(string)
+	sortStr, _ := args["sort"].(string)
+
+	sortKeys, err := agenda.ParseSortKeys(sortStr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid sort: %v", err)), nil
+	}
 
 	date := time.Now()
 	if dateStr != "" {
-		parsed, err := time.Parse("2006-01-02", dateStr)
+		parsed, err := dateinput.Parse(dateStr, date)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Invalid date format: %v", err)), nil
 		}
-		date = parsed
+		date = parsed.Time
 	}
 
 	if rangeType == "" {
@@ -191,6 +771,9 @@ func (s *Server) handleGetAgenda(ctx context.Context, request mcp.CallToolReques
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get agenda: %v", err)), nil
 	}
+	if err := agenda.SortItems(items, sortKeys); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid sort: %v", err)), nil
+	}
 
 	return mcp.NewToolResultJSON(map[string]interface{}{
 		"items": items,
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
 	"github.com/garaemon/org-agenda-cli/pkg/service"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -131,10 +132,10 @@ func TestHandleAddTodo_Success(t *testing.T) {
 	if !strings.Contains(strContent, "TODO [#A] New Task :urgent:important:") {
 		t.Errorf("File missing expected task header, got: %s", strContent)
 	}
-	if !strings.Contains(strContent, "SCHEDULED: <2023-10-10>") {
+	if !strings.Contains(strContent, "SCHEDULED: <2023-10-10 Tue>") {
 		t.Errorf("File missing schedule, got: %s", strContent)
 	}
-	if !strings.Contains(strContent, "DEADLINE: <2023-10-15>") {
+	if !strings.Contains(strContent, "DEADLINE: <2023-10-15 Sun>") {
 		t.Errorf("File missing deadline, got: %s", strContent)
 	}
 }
@@ -424,3 +425,384 @@ func TestRegisterTools(t *testing.T) {
 	// registerTools should not panic
 	s.registerTools()
 }
+
+func TestHandleMarkDone_ByID(t *testing.T) {
+	filePath := createTempOrgFile(t, "* TODO Task 1\n:PROPERTIES:\n:ID: task-1\n:END:\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	s := NewServer(svc)
+
+	req := createCallToolRequest("mark_done", map[string]interface{}{
+		"id": "task-1",
+	})
+	result, err := s.handleMarkDone(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleMarkDone returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleMarkDone returned tool error: %v", result.Content)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "* DONE Task 1") {
+		t.Errorf("Task not marked as DONE, got: %s", string(content))
+	}
+}
+
+func TestHandleMarkDone_FingerprintConflict(t *testing.T) {
+	filePath := createTempOrgFile(t, "* TODO Task 1\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	s := NewServer(svc)
+
+	req := createCallToolRequest("mark_done", map[string]interface{}{
+		"id":          filePath + ":1",
+		"fingerprint": "0000000000000000-0",
+	})
+	result, err := s.handleMarkDone(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleMarkDone returned unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected tool error for a stale fingerprint")
+	}
+
+	content, _ := os.ReadFile(filePath)
+	if strings.Contains(string(content), "DONE") {
+		t.Errorf("Task must not be modified on conflict, got: %s", content)
+	}
+}
+
+func TestHandleSetState(t *testing.T) {
+	filePath := createTempOrgFile(t, "* TODO Task 1\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	svc.ParseOptions.LogDrawer = "LOGBOOK"
+	s := NewServer(svc)
+
+	req := createCallToolRequest("set_state", map[string]interface{}{
+		"id":    filePath + ":1",
+		"state": "WAITING",
+		"note":  "Blocked on review",
+	})
+	result, err := s.handleSetState(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleSetState returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleSetState returned tool error: %v", result.Content)
+	}
+
+	content, _ := os.ReadFile(filePath)
+	got := string(content)
+	if !strings.HasPrefix(got, "* WAITING Task 1\n:LOGBOOK:\n- State \"WAITING\"") || !strings.Contains(got, "  Blocked on review\n:END:") {
+		t.Errorf("Unexpected content: %s", got)
+	}
+
+	req = createCallToolRequest("set_state", map[string]interface{}{
+		"id":    filePath + ":1",
+		"state": "NEXT",
+	})
+	result, _ = s.handleSetState(context.Background(), req)
+	if !result.IsError {
+		t.Error("Expected tool error for an unknown keyword")
+	}
+}
+
+func TestHandleScheduleTask(t *testing.T) {
+	filePath := createTempOrgFile(t, "* TODO Task 1\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	s := NewServer(svc)
+
+	req := createCallToolRequest("schedule_task", map[string]interface{}{
+		"id":   filePath + ":1",
+		"date": "2026-01-05 10:00",
+	})
+	result, err := s.handleScheduleTask(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleScheduleTask returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleScheduleTask returned tool error: %v", result.Content)
+	}
+	content, _ := os.ReadFile(filePath)
+	if string(content) != "* TODO Task 1\nSCHEDULED: <2026-01-05 Mon 10:00>\n" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	req = createCallToolRequest("schedule_task", map[string]interface{}{
+		"id": filePath + ":1",
+	})
+	result, _ = s.handleScheduleTask(context.Background(), req)
+	if !result.IsError {
+		t.Error("Expected tool error without date or remove")
+	}
+
+	req = createCallToolRequest("schedule_task", map[string]interface{}{
+		"id":     filePath + ":1",
+		"remove": true,
+	})
+	result, _ = s.handleScheduleTask(context.Background(), req)
+	if result.IsError {
+		t.Fatalf("handleScheduleTask returned tool error: %v", result.Content)
+	}
+	content, _ = os.ReadFile(filePath)
+	if string(content) != "* TODO Task 1\n" {
+		t.Errorf("Expected the schedule to be removed, got %q", content)
+	}
+}
+
+func TestHandleEditTagsAndPriority(t *testing.T) {
+	filePath := createTempOrgFile(t, "* TODO Task 1 :work:\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	svc.TagsColumn = 0
+	s := NewServer(svc)
+
+	req := createCallToolRequest("edit_tags", map[string]interface{}{
+		"id":     filePath + ":1",
+		"add":    "home, errand",
+		"remove": "work",
+	})
+	result, err := s.handleEditTags(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleEditTags returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleEditTags returned tool error: %v", result.Content)
+	}
+
+	req = createCallToolRequest("set_priority", map[string]interface{}{
+		"id":       filePath + ":1",
+		"priority": "A",
+	})
+	result, err = s.handleSetPriority(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleSetPriority returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleSetPriority returned tool error: %v", result.Content)
+	}
+
+	content, _ := os.ReadFile(filePath)
+	if string(content) != "* TODO [#A] Task 1 :home:errand:\n" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	req = createCallToolRequest("edit_tags", map[string]interface{}{
+		"id": filePath + ":1",
+	})
+	result, _ = s.handleEditTags(context.Background(), req)
+	if !result.IsError {
+		t.Error("Expected tool error without any tag change")
+	}
+}
+
+func TestHandleRefile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	source := createTempOrgFile(t, "* TODO Task\n** Child\n")
+	target := createTempOrgFile(t, "* Projects\n")
+	svc := service.NewService([]string{source, target}, source)
+	s := NewServer(svc)
+
+	req := createCallToolRequest("refile", map[string]interface{}{
+		"id":   source + ":1",
+		"file": target,
+		"olp":  "Projects",
+	})
+	result, err := s.handleRefile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleRefile returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleRefile returned tool error: %v", result.Content)
+	}
+
+	content, _ := os.ReadFile(target)
+	if string(content) != "* Projects\n** TODO Task\n*** Child\n" {
+		t.Errorf("Unexpected target content: %q", content)
+	}
+
+	result, err = s.handleListRefileTargets(context.Background(), createCallToolRequest("list_refile_targets", nil))
+	if err != nil || result.IsError {
+		t.Fatalf("handleListRefileTargets failed: %v %v", err, result.Content)
+	}
+}
+
+func TestHandleEditTree(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	filePath := createTempOrgFile(t, "* A\n* B\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	s := NewServer(svc)
+
+	req := createCallToolRequest("edit_tree", map[string]interface{}{
+		"id":     filePath + ":2",
+		"action": "move-up",
+	})
+	result, err := s.handleEditTree(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleEditTree returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleEditTree returned tool error: %v", result.Content)
+	}
+	content, _ := os.ReadFile(filePath)
+	if string(content) != "* B\n* A\n" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	req = createCallToolRequest("edit_tree", map[string]interface{}{
+		"id":     filePath + ":1",
+		"action": "sideways",
+	})
+	result, _ = s.handleEditTree(context.Background(), req)
+	if !result.IsError {
+		t.Error("Expected an error for an unknown action")
+	}
+}
+
+func TestHandleBlocked(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	filePath := createTempOrgFile(t, "* TODO Parent\n** TODO Child\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	s := NewServer(svc)
+
+	result, err := s.handleListTodos(context.Background(), createCallToolRequest("list_todos", map[string]interface{}{
+		"blocked": true,
+	}))
+	if err != nil {
+		t.Fatalf("handleListTodos returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleListTodos returned tool error: %v", result.Content)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, `"blockedBy":["`+filePath+`:2"]`) || strings.Contains(text, "Child") {
+		t.Errorf("Expected only the parent with its blocker, got: %s", text)
+	}
+
+	result, err = s.handleMarkDone(context.Background(), createCallToolRequest("mark_done", map[string]interface{}{
+		"id": filePath + ":1",
+	}))
+	if err != nil {
+		t.Fatalf("handleMarkDone returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected a tool error for a blocked task")
+	}
+
+	result, err = s.handleMarkDone(context.Background(), createCallToolRequest("mark_done", map[string]interface{}{
+		"id":    filePath + ":1",
+		"force": true,
+	}))
+	if err != nil {
+		t.Fatalf("handleMarkDone returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleMarkDone returned tool error: %v", result.Content)
+	}
+}
+
+func TestHandleAddNote(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	filePath := createTempOrgFile(t, "* TODO Task 1\n:PROPERTIES:\n:ID: task-1\n:END:\n")
+	svc := service.NewService([]string{filePath}, filePath)
+	s := NewServer(svc)
+
+	result, err := s.handleAddNote(context.Background(), createCallToolRequest("add_note", map[string]interface{}{
+		"id":   "task-1",
+		"note": "Called the vendor",
+	}))
+	if err != nil {
+		t.Fatalf("handleAddNote returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleAddNote returned tool error: %v", result.Content)
+	}
+
+	content, _ := os.ReadFile(filePath)
+	if !strings.Contains(string(content), ":LOGBOOK:\n- Note taken on [") || !strings.Contains(string(content), "\n  Called the vendor\n:END:") {
+		t.Errorf("Expected the note in the LOGBOOK, got: %s", content)
+	}
+
+	result, err = s.handleAddNote(context.Background(), createCallToolRequest("add_note", map[string]interface{}{
+		"id": "task-1",
+	}))
+	if err != nil {
+		t.Fatalf("handleAddNote returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected a tool error without a note")
+	}
+}
+
+func TestHandleCapture(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	filePath := createTempOrgFile(t, "* Meetings\n")
+	projects := createTempOrgFile(t, "* Website\n:PROPERTIES:\n:ID: website\n:END:\n")
+	svc := service.NewService([]string{filePath, projects}, filePath)
+	svc.CaptureTemplates = []capture.Template{
+		{Key: "m", Type: capture.TypeEntry, Target: capture.TargetHeadline, File: filePath, Headline: "Meetings", Format: "* %c with %^{Who} %^g"},
+		{Key: "n", Type: capture.TypePlain, Target: capture.TargetHeadline, File: filePath, Headline: "Meetings", Format: "  - %i %a"},
+	}
+	s := NewServer(svc)
+
+	result, err := s.handleCapture(context.Background(), createCallToolRequest("capture", map[string]interface{}{
+		"template": "m",
+		"content":  "Sync",
+		"vars":     map[string]interface{}{"Who": "Alice", "Tags": "work"},
+	}))
+	if err != nil {
+		t.Fatalf("handleCapture returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleCapture returned tool error: %v", result.Content)
+	}
+	content, _ := os.ReadFile(filePath)
+	if string(content) != "* Meetings\n** Sync with Alice :work:\n" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	result, err = s.handleCapture(context.Background(), createCallToolRequest("capture", map[string]interface{}{
+		"template": "n",
+		"content":  "unused",
+		"initial":  "first\nsecond",
+		"link":     "file:/notes.txt",
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleCapture failed: %v %v", err, result.Content)
+	}
+	content, _ = os.ReadFile(filePath)
+	if string(content) != "* Meetings\n  - first\n    second [[file:/notes.txt]]\n** Sync with Alice :work:\n" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	result, err = s.handleCapture(context.Background(), createCallToolRequest("capture", map[string]interface{}{
+		"template": "m",
+		"content":  "Kickoff",
+		"under":    "website",
+		"vars":     map[string]interface{}{"Who": "Bob", "Tags": "web"},
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleCapture failed: %v %v", err, result.Content)
+	}
+	content, _ = os.ReadFile(projects)
+	if string(content) != "* Website\n:PROPERTIES:\n:ID: website\n:END:\n** Kickoff with Bob :web:\n" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"template": "m", "content": "Sync"},
+		{"template": "n", "content": "Lost", "under": "missing"},
+		{"content": "Which template?"},
+		{"template": "x", "content": "Unknown"},
+	} {
+		result, err = s.handleCapture(context.Background(), createCallToolRequest("capture", args))
+		if err != nil {
+			t.Fatalf("handleCapture returned error: %v", err)
+		}
+		if !result.IsError {
+			t.Errorf("Expected a tool error for %v", args)
+		}
+	}
+}
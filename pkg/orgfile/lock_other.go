@@ -0,0 +1,39 @@
+//go:build !unix
+
+package orgfile
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	locksMu sync.Mutex
+	locks   = map[string]bool{}
+)
+
+// acquire falls back to an in-process lock on platforms without flock. It
+// still serializes concurrent MCP tool calls, which share one process.
+func acquire(lockPath, target string) (func(), error) {
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		locksMu.Lock()
+		if !locks[lockPath] {
+			locks[lockPath] = true
+			locksMu.Unlock()
+			break
+		}
+		locksMu.Unlock()
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, target)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return func() {
+		locksMu.Lock()
+		delete(locks, lockPath)
+		locksMu.Unlock()
+	}, nil
+}
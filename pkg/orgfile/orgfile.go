@@ -0,0 +1,183 @@
+// Package orgfile is the single place where Org files are written. Writes go
+// to a temporary file that is renamed over the original, so a crash never
+// leaves a half-written file behind, and every read-modify-write cycle runs
+// under an advisory lock so concurrent invocations (or concurrent MCP tool
+// calls) cannot clobber each other.
+package orgfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// LockTimeout bounds how long Lock waits for another writer.
+var LockTimeout = 10 * time.Second
+
+// Warn reports non-fatal conditions such as an Emacs buffer holding a lock on
+// the file. It writes to stderr so that it never interferes with the MCP
+// stdio transport.
+var Warn = func(msg string) {
+	fmt.Fprintln(os.Stderr, "Warning:", msg)
+}
+
+// ErrLocked is returned when the lock of a file could not be acquired within
+// LockTimeout.
+var ErrLocked = errors.New("file is locked by another process")
+
+// Update locks path, passes its current content to fn (nil when the file does
+// not exist yet) and atomically replaces the file with the returned content.
+// Returning the content unchanged skips the write.
+func Update(path string, fn func(content []byte) ([]byte, error)) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	updated, err := fn(old)
+	if err != nil {
+		return err
+	}
+	if old != nil && string(updated) == string(old) {
+		return nil
+	}
+	return WriteFile(path, updated)
+}
+
+// WriteFile atomically replaces path with data, keeping the permission bits
+// of the existing file. Symlinks are followed so that the link itself stays
+// in place. Callers are expected to hold the lock of path.
+func WriteFile(path string, data []byte) error {
+	target, err := resolve(path)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }() // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// Lock takes the advisory lock of path and returns a function releasing it.
+// The lock lives in a separate file under the user's cache directory because
+// the Org file itself is replaced on every write. An Emacs lock (.#file) is
+// reported through Warn but does not block the write.
+func Lock(path string) (func(), error) {
+	target, err := resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	checkEmacsLock(target)
+
+	lockPath, err := lockFilePath(target)
+	if err != nil {
+		return nil, err
+	}
+	return acquire(lockPath, target)
+}
+
+// LockAll locks every path in a stable order so that two processes locking
+// the same set of files cannot deadlock.
+func LockAll(paths ...string) (func(), error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+
+	var unlocks []func()
+	release := func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+	seen := map[string]bool{}
+	for _, p := range sorted {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		unlock, err := Lock(p)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		unlocks = append(unlocks, unlock)
+	}
+	return release, nil
+}
+
+// resolve returns the absolute path of the file behind path, following
+// symlinks when the file exists.
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+func lockFilePath(target string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, "org-agenda-cli", "locks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(target))
+	return filepath.Join(dir, hex.EncodeToString(sum[:12])+".lock"), nil
+}
+
+// checkEmacsLock warns when Emacs has unsaved changes to target, which it
+// signals with a ".#name" symlink next to the file.
+func checkEmacsLock(target string) {
+	lock := filepath.Join(filepath.Dir(target), ".#"+filepath.Base(target))
+	owner, err := os.Readlink(lock)
+	if err != nil {
+		return
+	}
+	Warn(fmt.Sprintf("%s is being edited in Emacs (%s); unsaved changes there may overwrite this edit", target, owner))
+}
@@ -0,0 +1,206 @@
+package orgfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteFile_PreservesMode(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "test.org")
+	if err := os.WriteFile(path, []byte("* TODO Task\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("* DONE Task\n")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "* DONE Task\n" {
+		t.Errorf("content = %q", content)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFile_FollowsSymlink(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.org")
+	link := filepath.Join(dir, "link.org")
+	if err := os.WriteFile(target, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if err := WriteFile(link, []byte("new\n")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link was replaced by a regular file")
+	}
+	content, _ := os.ReadFile(target)
+	if string(content) != "new\n" {
+		t.Errorf("target content = %q, want %q", content, "new\n")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "test.org")
+
+	t.Run("creates missing file", func(t *testing.T) {
+		err := Update(path, func(content []byte) ([]byte, error) {
+			if content != nil {
+				t.Errorf("expected nil content for a missing file, got %q", content)
+			}
+			return []byte("* TODO Task\n"), nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		content, _ := os.ReadFile(path)
+		if string(content) != "* TODO Task\n" {
+			t.Errorf("content = %q", content)
+		}
+	})
+
+	t.Run("skips unchanged content", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+		err := Update(path, func(content []byte) ([]byte, error) {
+			return content, nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		info, _ := os.Stat(path)
+		if !info.ModTime().Equal(past) {
+			t.Errorf("file was rewritten although the content did not change")
+		}
+	})
+
+	t.Run("keeps file on error", func(t *testing.T) {
+		wantErr := errors.New("boom")
+		err := Update(path, func(content []byte) ([]byte, error) {
+			return nil, wantErr
+		})
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("err = %v, want %v", err, wantErr)
+		}
+		content, _ := os.ReadFile(path)
+		if string(content) != "* TODO Task\n" {
+			t.Errorf("content = %q", content)
+		}
+	})
+}
+
+func TestUpdate_Concurrent(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "counter.org")
+	if err := os.WriteFile(path, []byte("0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, func(content []byte) ([]byte, error) {
+				n, err := strconv.Atoi(strings.TrimSpace(string(content)))
+				if err != nil {
+					return nil, err
+				}
+				return []byte(strconv.Itoa(n + 1)), nil
+			})
+			if err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	content, _ := os.ReadFile(path)
+	if string(content) != strconv.Itoa(workers) {
+		t.Errorf("counter = %s, want %d", content, workers)
+	}
+}
+
+func TestLock_Timeout(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "test.org")
+
+	old := LockTimeout
+	LockTimeout = 50 * time.Millisecond
+	defer func() { LockTimeout = old }()
+
+	unlock, err := LockAll(path, path)
+	if err != nil {
+		t.Fatalf("LockAll failed: %v", err)
+	}
+	if _, err := Lock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("err = %v, want ErrLocked", err)
+	}
+	unlock()
+
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("Lock after release failed: %v", err)
+	}
+	unlock()
+}
+
+func TestLock_EmacsWarning(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.org")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("user@host.1234:1700000000", filepath.Join(dir, ".#test.org")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	var warnings []string
+	oldWarn := Warn
+	Warn = func(msg string) { warnings = append(warnings, msg) }
+	defer func() { Warn = oldWarn }()
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	unlock()
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Emacs") {
+		t.Errorf("warnings = %v, want one Emacs warning", warnings)
+	}
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationUnits mirrors org-duration-units.
+var durationUnits = map[string]time.Duration{
+	"min": time.Minute,
+	"h":   time.Hour,
+	"d":   24 * time.Hour,
+	"w":   7 * 24 * time.Hour,
+	"m":   30 * 24 * time.Hour,
+	"y":   365 * 24 * time.Hour,
+}
+
+var (
+	hmmRegex      = regexp.MustCompile(`^(\d+):([0-5]\d)(?::([0-5]\d))?$`)
+	unitPartRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(min|h|d|w|m|y)$`)
+)
+
+// ParseDuration parses an Org duration such as "1:30", "0:45", "3h",
+// "1d 2h" or "1d 2:30". A bare number is read as minutes, as Org does for
+// effort values.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Minute, nil
+	}
+
+	var total time.Duration
+	for _, part := range strings.Fields(s) {
+		if m := hmmRegex.FindStringSubmatch(part); m != nil {
+			h, _ := strconv.Atoi(m[1])
+			mins, _ := strconv.Atoi(m[2])
+			total += time.Duration(h)*time.Hour + time.Duration(mins)*time.Minute
+			if m[3] != "" {
+				sec, _ := strconv.Atoi(m[3])
+				total += time.Duration(sec) * time.Second
+			}
+			continue
+		}
+		if m := unitPartRegex.FindStringSubmatch(part); m != nil {
+			n, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			total += time.Duration(n * float64(durationUnits[m[2]]))
+			continue
+		}
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return total, nil
+}
+
+// FormatDuration renders d in Org's "H:MM" notation.
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%s%d:%02d", sign, minutes/60, minutes%60)
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{input: "1:30", expected: 90 * time.Minute},
+		{input: "0:45", expected: 45 * time.Minute},
+		{input: "3h", expected: 3 * time.Hour},
+		{input: "1d 2h", expected: 26 * time.Hour},
+		{input: "1d 2:30", expected: 26*time.Hour + 30*time.Minute},
+		{input: "30min", expected: 30 * time.Minute},
+		{input: "1.5h", expected: 90 * time.Minute},
+		{input: "45", expected: 45 * time.Minute},
+		{input: "", wantErr: true},
+		{input: "soon", wantErr: true},
+		{input: "1:75", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	if got := FormatDuration(9*time.Hour + 30*time.Minute); got != "9:30" {
+		t.Errorf("FormatDuration() = %q, want 9:30", got)
+	}
+	if got := FormatDuration(5 * time.Minute); got != "0:05" {
+		t.Errorf("FormatDuration() = %q, want 0:05", got)
+	}
+}
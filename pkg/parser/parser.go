@@ -4,40 +4,113 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/garaemon/org-agenda-cli/pkg/item"
 )
 
 var (
-	headlineRegex  = regexp.MustCompile(fmt.Sprintf(`^\*+\s+(?:(%s|%s|%s)\s+)?(?:\[#([A-Za-z0-9])\]\s+)?(.*?)(?:\s+:(.*):)?\s*$`, item.StatusTodo, item.StatusDone, item.StatusWaiting))
-	timestampRegex = regexp.MustCompile(`<(\d{4}-\d{2}-\d{2})[^>]*>`)
+	timestampRegex = regexp.MustCompile(`[<\[](\d{4}-\d{2}-\d{2})[^>\]]*[>\]]`)
+	keywordRegex   = regexp.MustCompile(`^#\+([A-Za-z_]+):\s*(.*?)\s*$`)
+	propertyRegex  = regexp.MustCompile(`^\s*:([^:\s]+):(?:\s+(.*?))?\s*$`)
+	keywordSpecRe  = regexp.MustCompile(`^([^\s()|]+)(?:\(([^@!/)])?([@!])?(?:/([@!]))?\))?$`)
+
+	headlineRegexMu    sync.Mutex
+	headlineRegexCache = map[string]*regexp.Regexp{}
 )
 
+// DefaultArchiveLocation is Org's default org-archive-location: a file next
+// to the source named after it with an "_archive" suffix.
+const DefaultArchiveLocation = "%s_archive::"
+
+// Options holds the settings that influence parsing. In-buffer keywords such
+// as #+PRIORITIES or #+TODO take precedence over them for the file they
+// appear in. LogDrawer is the drawer state changes are logged into ("" logs
+// them directly below the headline), which #+STARTUP: logdrawer and
+// nologdrawer override. LogReschedule and LogRedeadline select what is logged
+// when a scheduled date or deadline changes (item.LogNone, LogTime or
+// LogNote), like org-log-reschedule and org-log-redeadline. ArchiveLocation
+// mirrors org-archive-location and #+ARCHIVE, and Category is the file's
+// #+CATEGORY ("" for the file name).
+type Options struct {
+	Priorities      item.PriorityRange
+	Keywords        item.Keywords
+	LogDrawer       string
+	LogReschedule   string
+	LogRedeadline   string
+	ArchiveLocation string
+	Category        string
+}
+
+// DefaultOptions returns the options matching Org's built-in defaults.
+func DefaultOptions() Options {
+	return Options{
+		Priorities:      item.DefaultPriorityRange,
+		Keywords:        item.DefaultKeywords,
+		ArchiveLocation: DefaultArchiveLocation,
+	}
+}
+
+// FileOptions returns opts with the in-buffer settings of content applied.
+func FileOptions(content string, opts Options) Options {
+	return applyInBufferSettings(strings.Split(content, "\n"), opts)
+}
+
 // ParseString parses a string containing Org-mode content.
 func ParseString(content string, filePath string) []*item.Item {
+	return ParseStringWithOptions(content, filePath, DefaultOptions())
+}
+
+// ParseStringWithOptions parses a string containing Org-mode content using
+// opts for any setting the file does not override itself.
+func ParseStringWithOptions(content string, filePath string, opts Options) []*item.Item {
 	lines := strings.Split(content, "\n")
+	opts = applyInBufferSettings(lines, opts)
+
 	var items []*item.Item
 	var currentItem *item.Item
+	inProperties := false
 
 	for i, line := range lines {
 		if strings.HasPrefix(line, "*") {
-			currentItem = ParseHeadline(line)
+			inProperties = false
+			currentItem = ParseHeadlineWithKeywords(line, opts.Keywords)
 			if currentItem != nil {
 				currentItem.FilePath = filePath
 				currentItem.LineNumber = i + 1
+				currentItem.Priorities = opts.Priorities
+				currentItem.EffectivePriority = currentItem.Priority
+				if currentItem.EffectivePriority == "" {
+					currentItem.EffectivePriority = opts.Priorities.Default
+				}
 				items = append(items, currentItem)
 			}
 			continue
 		}
 
 		if currentItem != nil {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case strings.EqualFold(trimmed, ":PROPERTIES:"):
+				inProperties = true
+			case inProperties && strings.EqualFold(trimmed, ":END:"):
+				inProperties = false
+			case inProperties:
+				if m := propertyRegex.FindStringSubmatch(line); m != nil {
+					setProperty(currentItem, m[1], m[2])
+				}
+			}
+
 			if sched := ParseTimestamp(line, "SCHEDULED"); sched != nil {
 				currentItem.Scheduled = sched
 			}
 			if dead := ParseTimestamp(line, "DEADLINE"); dead != nil {
 				currentItem.Deadline = dead
 			}
+			if closed := ParseTimestamp(line, "CLOSED"); closed != nil {
+				currentItem.Closed = closed
+			}
 			// For RawContent, we append lines that are not headlines or special metadata
 			// In a more complex implementation, we would handle properties etc.
 			if !strings.Contains(line, "SCHEDULED:") && !strings.Contains(line, "DEADLINE:") {
@@ -53,9 +126,177 @@ func ParseString(content string, filePath string) []*item.Item {
 	return items
 }
 
-// ParseHeadline parses a single line as an Org headline.
+// setProperty records a property of it. Keys are case-insensitive in Org, so
+// they are stored upper-cased.
+func setProperty(it *item.Item, key, value string) {
+	key = strings.ToUpper(key)
+	if it.Properties == nil {
+		it.Properties = map[string]string{}
+	}
+	it.Properties[key] = value
+
+	switch key {
+	case "ID":
+		it.ID = value
+	case "CUSTOM_ID":
+		it.CustomID = value
+	case "EFFORT":
+		it.Effort = value
+		if d, err := ParseDuration(value); err == nil {
+			it.EffortMinutes = int(d / time.Minute)
+		}
+	}
+}
+
+// applyInBufferSettings overrides opts with the #+KEYWORD lines found in the
+// file. Like Org, only the first occurrence of a keyword is honored, except
+// for #+TODO and its variants whose sequences add up.
+func applyInBufferSettings(lines []string, opts Options) Options {
+	seen := map[string]bool{}
+	var todoSpecs []string
+	for _, line := range lines {
+		matches := keywordRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		key := strings.ToUpper(matches[1])
+		switch key {
+		case "TODO", "SEQ_TODO", "TYP_TODO":
+			todoSpecs = append(todoSpecs, matches[2])
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch key {
+		case "PRIORITIES":
+			if r, ok := ParsePriorities(matches[2]); ok {
+				opts.Priorities = r
+			}
+		case "ARCHIVE":
+			opts.ArchiveLocation = matches[2]
+		case "CATEGORY":
+			opts.Category = matches[2]
+		case "STARTUP":
+			for _, option := range strings.Fields(matches[2]) {
+				switch option {
+				case "logdrawer":
+					opts.LogDrawer = "LOGBOOK"
+				case "nologdrawer":
+					opts.LogDrawer = ""
+				case "logreschedule":
+					opts.LogReschedule = item.LogTime
+				case "lognotereschedule":
+					opts.LogReschedule = item.LogNote
+				case "nologreschedule":
+					opts.LogReschedule = item.LogNone
+				case "logredeadline":
+					opts.LogRedeadline = item.LogTime
+				case "lognoteredeadline":
+					opts.LogRedeadline = item.LogNote
+				case "nologredeadline":
+					opts.LogRedeadline = item.LogNone
+				}
+			}
+		}
+	}
+	if len(todoSpecs) > 0 {
+		if kws, err := ParseTodoKeywords(todoSpecs...); err == nil {
+			opts.Keywords = kws
+		}
+	}
+	return opts
+}
+
+// ParseTodoKeywords parses keyword sequences in the syntax of
+// org-todo-keywords and #+TODO, e.g. "TODO WAIT(w@/!) | DONE(d!) CANCELED".
+// Keywords after the "|" are done states; without a "|" only the last
+// keyword of the sequence is.
+func ParseTodoKeywords(specs ...string) (item.Keywords, error) {
+	var kws item.Keywords
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		fields := strings.Fields(spec)
+		if len(fields) == 0 {
+			continue
+		}
+		done := false
+		sep := false
+		var seq item.Keywords
+		for _, field := range fields {
+			if field == "|" {
+				if sep {
+					return nil, fmt.Errorf("more than one \"|\" in %q", spec)
+				}
+				sep, done = true, true
+				continue
+			}
+			m := keywordSpecRe.FindStringSubmatch(field)
+			if m == nil {
+				return nil, fmt.Errorf("invalid keyword %q", field)
+			}
+			if seen[m[1]] {
+				return nil, fmt.Errorf("keyword %s is declared twice", m[1])
+			}
+			seen[m[1]] = true
+			seq = append(seq, item.Keyword{
+				Name:  m[1],
+				Key:   m[2],
+				Done:  done,
+				Enter: logMarker(m[3]),
+				Leave: logMarker(m[4]),
+			})
+		}
+		if !sep && len(seq) > 0 {
+			seq[len(seq)-1].Done = true
+		}
+		kws = append(kws, seq...)
+	}
+	if len(kws) == 0 {
+		return nil, fmt.Errorf("no TODO keywords declared")
+	}
+	return kws, nil
+}
+
+func logMarker(marker string) string {
+	switch marker {
+	case "!":
+		return item.LogTime
+	case "@":
+		return item.LogNote
+	}
+	return item.LogNone
+}
+
+// ParsePriorities parses the value of a #+PRIORITIES keyword, which lists the
+// highest, lowest and default priority in that order (e.g. "A E C").
+func ParsePriorities(value string) (item.PriorityRange, bool) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return item.PriorityRange{}, false
+	}
+	r := item.PriorityRange{Highest: fields[0], Lowest: fields[1], Default: fields[2]}
+	if !r.Valid() {
+		return item.PriorityRange{}, false
+	}
+	return r, true
+}
+
+// ParseHeadline parses a single line as an Org headline, recognizing the
+// default TODO keywords.
 func ParseHeadline(line string) *item.Item {
-	matches := headlineRegex.FindStringSubmatch(line)
+	return ParseHeadlineWithKeywords(line, item.DefaultKeywords)
+}
+
+// ParseHeadlineWithKeywords parses a single line as an Org headline whose
+// TODO keyword is one of kws. An empty kws means the default keywords.
+func ParseHeadlineWithKeywords(line string, kws item.Keywords) *item.Item {
+	if len(kws) == 0 {
+		kws = item.DefaultKeywords
+	}
+	matches := headlineRegexFor(kws).FindStringSubmatch(line)
 	if matches == nil {
 		return nil
 	}
@@ -85,18 +326,42 @@ func ParseHeadline(line string) *item.Item {
 		Title:    title,
 		Level:    level,
 		Status:   status,
+		Done:     kws.IsDone(status),
 		Priority: priority,
 		Tags:     tags,
 	}
 }
 
-// ParseTimestamp extracts a timestamp for a given key (e.g., SCHEDULED, DEADLINE).
+// headlineRegexFor returns the headline pattern matching the keywords kws.
+// Patterns are cached because the same few keyword sets are used for every
+// line of every file.
+func headlineRegexFor(kws item.Keywords) *regexp.Regexp {
+	names := make([]string, len(kws))
+	for i, kw := range kws {
+		names[i] = regexp.QuoteMeta(kw.Name)
+	}
+	alternatives := strings.Join(names, "|")
+
+	headlineRegexMu.Lock()
+	defer headlineRegexMu.Unlock()
+	if re, ok := headlineRegexCache[alternatives]; ok {
+		return re
+	}
+	re := regexp.MustCompile(fmt.Sprintf(`^\*+\s+(?:(%s)\s+)?(?:\[#([A-Za-z0-9])\]\s+)?(.*?)(?:\s+:(.*):)?\s*$`, alternatives))
+	headlineRegexCache[alternatives] = re
+	return re
+}
+
+// ParseTimestamp extracts a timestamp for a given key (e.g., SCHEDULED, DEADLINE,
+// CLOSED). The timestamp following the key is used, so one planning line may
+// carry several keys. Both active and inactive timestamps are accepted.
 func ParseTimestamp(line string, key string) *time.Time {
-	if !strings.Contains(line, key+":") {
+	pos := strings.Index(line, key+":")
+	if pos == -1 {
 		return nil
 	}
 
-	matches := timestampRegex.FindStringSubmatch(line)
+	matches := timestampRegex.FindStringSubmatch(line[pos:])
 	if len(matches) < 2 {
 		return nil
 	}
@@ -108,3 +373,17 @@ func ParseTimestamp(line string, key string) *time.Time {
 
 	return &t
 }
+
+// FormatTimestamp renders t as an Org timestamp: "<2026-01-05 Mon>" when
+// active, "[2026-01-05 Mon]" otherwise, with the time of day appended when
+// withTime is set.
+func FormatTimestamp(t time.Time, active, withTime bool) string {
+	layout := "2006-01-02 Mon"
+	if withTime {
+		layout += " 15:04"
+	}
+	if active {
+		return "<" + t.Format(layout) + ">"
+	}
+	return "[" + t.Format(layout) + "]"
+}
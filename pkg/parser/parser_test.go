@@ -150,6 +150,12 @@ func TestParseTimestamp(t *testing.T) {
 			key:      "DEADLINE",
 			expected: ptrTime(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)),
 		},
+		{
+			name:     "Deadline after scheduled on one line",
+			line:     "SCHEDULED: <2026-01-01 Thu> DEADLINE: <2026-01-05 Mon 10:00>",
+			key:      "DEADLINE",
+			expected: ptrTime(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)),
+		},
 	}
 
 	for _, tt := range tests {
@@ -169,3 +175,138 @@ func TestParseTimestamp(t *testing.T) {
 func ptrTime(t time.Time) *time.Time {
 	return &t
 }
+
+func TestParseStringPriorities(t *testing.T) {
+	content := `#+PRIORITIES: 1 9 5
+* TODO [#2] Explicit
+* TODO Implicit
+`
+	items := ParseString(content, "test.org")
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].Priority != "2" || items[0].EffectivePriority != "2" {
+		t.Errorf("Unexpected priority for item 0: %+v", items[0])
+	}
+	if items[1].Priority != "" || items[1].EffectivePriority != "5" {
+		t.Errorf("Expected default priority 5 for item 1, got %+v", items[1])
+	}
+}
+
+func TestParseStringWithOptionsDefaultPriority(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Priorities = item.PriorityRange{Highest: "A", Lowest: "E", Default: "C"}
+
+	items := ParseStringWithOptions("* TODO Task\n", "test.org", opts)
+	if items[0].EffectivePriority != "C" {
+		t.Errorf("Expected effective priority C, got %q", items[0].EffectivePriority)
+	}
+
+	// The in-buffer keyword wins over the options.
+	items = ParseStringWithOptions("#+PRIORITIES: A C A\n* TODO Task\n", "test.org", opts)
+	if items[0].EffectivePriority != "A" {
+		t.Errorf("Expected effective priority A, got %q", items[0].EffectivePriority)
+	}
+	if want := (item.PriorityRange{Highest: "A", Lowest: "C", Default: "A"}); items[0].Priorities != want {
+		t.Errorf("Expected the range of the file, got %+v", items[0].Priorities)
+	}
+}
+
+func TestParsePriorities(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"A E C", true},
+		{"1 9 5", true},
+		{"A C", false},
+		{"C A B", false},
+		{"A C D", false},
+	}
+	for _, tt := range tests {
+		_, ok := ParsePriorities(tt.value)
+		if ok != tt.ok {
+			t.Errorf("ParsePriorities(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+		}
+	}
+}
+
+func TestParseStringProperties(t *testing.T) {
+	content := `* TODO Task
+SCHEDULED: <2026-01-05 Mon>
+:PROPERTIES:
+:Effort:   1:30
+:OWNER: alice
+:END:
+Body
+* TODO Other
+`
+	items := ParseString(content, "test.org")
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].Properties["OWNER"] != "alice" {
+		t.Errorf("Expected OWNER property, got %v", items[0].Properties)
+	}
+	if items[0].Effort != "1:30" || items[0].EffortMinutes != 90 {
+		t.Errorf("Unexpected effort: %q (%d minutes)", items[0].Effort, items[0].EffortMinutes)
+	}
+	if items[1].Properties != nil || items[1].Effort != "" {
+		t.Errorf("Properties leaked into the next item: %+v", items[1])
+	}
+}
+
+func TestParseTodoKeywords(t *testing.T) {
+	kws, err := ParseTodoKeywords("TODO WAIT(w@/!) | DONE(d!) CANCELED(c@)", "BUG FIXED")
+	if err != nil {
+		t.Fatalf("ParseTodoKeywords failed: %v", err)
+	}
+	expected := item.Keywords{
+		{Name: "TODO"},
+		{Name: "WAIT", Key: "w", Enter: item.LogNote, Leave: item.LogTime},
+		{Name: "DONE", Key: "d", Done: true, Enter: item.LogTime},
+		{Name: "CANCELED", Key: "c", Done: true, Enter: item.LogNote},
+		{Name: "BUG"},
+		{Name: "FIXED", Done: true},
+	}
+	if !reflect.DeepEqual(kws, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, kws)
+	}
+	if done, ok := kws.NextDone("BUG"); !ok || done.Name != "FIXED" {
+		t.Errorf("NextDone(BUG) = %+v, %v", done, ok)
+	}
+
+	for _, spec := range []string{"", "TODO | DONE | X", "TODO(x!!)", "TODO TODO"} {
+		if _, err := ParseTodoKeywords(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestParseStringInBufferTodoKeywords(t *testing.T) {
+	content := `#+TODO: NEXT | FINISHED
+#+TODO: BUG | FIXED
+#+STARTUP: logdrawer
+* NEXT Task
+* FIXED Bug
+* TODO Not a keyword here
+`
+	opts := FileOptions(content, DefaultOptions())
+	if opts.LogDrawer != "LOGBOOK" {
+		t.Errorf("Expected logdrawer to select LOGBOOK, got %q", opts.LogDrawer)
+	}
+
+	items := ParseString(content, "test.org")
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	if items[0].Status != "NEXT" || items[0].Done {
+		t.Errorf("Unexpected first item: %+v", items[0])
+	}
+	if items[1].Status != "FIXED" || !items[1].Done {
+		t.Errorf("Unexpected second item: %+v", items[1])
+	}
+	if items[2].Status != "" || items[2].Title != "TODO Not a keyword here" {
+		t.Errorf("Unexpected third item: %+v", items[2])
+	}
+}
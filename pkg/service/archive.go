@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// ArchiveResult describes where Archive moved, or would move, a subtree:
+// below Heading in File, or to the top level of File when Heading is empty.
+type ArchiveResult struct {
+	Item    *item.Item `json:"item"`
+	File    string     `json:"file"`
+	Heading string     `json:"heading,omitempty"`
+}
+
+// Location renders the destination in the syntax of org-archive-location.
+func (r ArchiveResult) Location() string {
+	return r.File + "::" + r.Heading
+}
+
+// Archive moves the subtree of the task referenced by ref to its archive
+// location, which like Org comes from the nearest :ARCHIVE: property, the
+// file's #+ARCHIVE keyword or the configured archive location, in that order.
+// The archived entry records where it came from in the ARCHIVE_TIME,
+// ARCHIVE_FILE, ARCHIVE_OLPATH, ARCHIVE_CATEGORY and ARCHIVE_TODO properties.
+// With dryRun nothing is written.
+func (s *Service) Archive(ref string, dryRun bool, opts EditOptions) (*ArchiveResult, error) {
+	if dryRun {
+		t, err := s.resolveForEdit(ref, opts)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(t.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		current, lines, err := s.locate(t, ref, string(content), opts)
+		if err != nil {
+			return nil, err
+		}
+		file, heading := s.archiveLocation(t.FilePath, lines, current.Line-1)
+		return &ArchiveResult{Item: current.Item, File: file, Heading: heading}, nil
+	}
+
+	var source, heading string
+	dest := func(lines []string, idx int, it *item.Item) (string, error) {
+		var file string
+		source = it.FilePath
+		file, heading = s.archiveLocation(it.FilePath, lines, idx)
+		return file, nil
+	}
+	moved, err := s.moveSubtree("archive "+ref, ref, opts, dest, func(m *subtreeMove) ([]string, int, error) {
+		m.Subtree = s.archiveContext(source, m.Lines, m.Start, m.Subtree)
+		dest, at := insertArchived(m.Dest, !m.InPlace, source, heading, m.Subtree)
+		return dest, at, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ArchiveResult{Item: moved.Item, File: moved.FilePath, Heading: heading}, nil
+}
+
+// ArchiveDone archives every done task closed more than olderThan ago. A
+// task below another archived task moves along with it. Tasks without a
+// CLOSED timestamp and tasks that are archived already are left alone. Like
+// Bulk, all changes are computed before anything is written and journaled
+// as a single operation.
+func (s *Service) ArchiveDone(olderThan time.Duration, dryRun bool) ([]*ArchiveResult, error) {
+	cutoff := now().Add(-olderThan)
+	plan := &bulkPlan{
+		s:    s,
+		opts: BulkOptions{Archive: true, DryRun: dryRun},
+		match: func(it *item.Item) bool {
+			return it.Done && it.Closed != nil && it.Closed.Before(cutoff)
+		},
+	}
+	if _, err := s.execute("archive done", plan); err != nil {
+		return nil, err
+	}
+
+	results := make([]*ArchiveResult, len(plan.archived))
+	for i, a := range plan.archived {
+		results[i] = &ArchiveResult{Item: a.item, File: a.dest, Heading: a.heading}
+	}
+	return results, nil
+}
+
+// isArchived reports whether the headline at idx of lines, which belong to
+// file, is archived already: it or an ancestor has the ARCHIVE tag, or it
+// lies below the heading of its archive location in file itself.
+func (s *Service) isArchived(file string, lines []string, idx int) bool {
+	items := parser.ParseStringWithOptions(strings.Join(lines, "\n"), file, s.ParseOptions)
+	dest, heading := s.archiveLocation(file, lines, idx)
+	inFile := heading != "" && sameFile(dest, file)
+	path := outlinePath(items, idx)
+	for i, it := range path {
+		for _, tag := range it.Tags {
+			if tag == "ARCHIVE" {
+				return true
+			}
+		}
+		if inFile && i < len(path)-1 && strings.TrimRight(lines[it.LineNumber-1], " \t") == heading {
+			return true
+		}
+	}
+	return false
+}
+
+// insertArchived inserts subtree below heading of the archive file with the
+// given lines, or at its end when heading is empty, and returns the new lines
+// and the index of the subtree's headline. The heading is added when missing,
+// and an empty file that is not the source itself gets the header Org writes
+// into new archive files.
+func insertArchived(lines []string, separate bool, source, heading string, subtree []string) ([]string, int) {
+	if len(lines) == 0 && separate {
+		lines = []string{"#    -*- mode: org -*-", "", "", "Archived entries from file " + absPath(source), ""}
+	}
+	parent, level := -1, 0
+	if heading != "" {
+		parent = findHeadlineLine(lines, heading)
+		if parent == -1 {
+			lines = append(lines, heading)
+			parent = len(lines) - 1
+		}
+		level = edit.HeadlineLevel(heading)
+	}
+	return insertSubtree(lines, parent, level, subtree)
+}
+
+// archiveLocation returns the archive file and heading for the entry at idx
+// of lines, which belong to file. As in Org, "%s" stands for the name of the
+// file, a relative archive file is relative to it and an empty one is file
+// itself.
+func (s *Service) archiveLocation(file string, lines []string, idx int) (string, string) {
+	content := strings.Join(lines, "\n")
+	location := parser.FileOptions(content, s.ParseOptions).ArchiveLocation
+	items := parser.ParseStringWithOptions(content, file, s.ParseOptions)
+	if value, ok := inheritedProperty(items, idx, "ARCHIVE"); ok {
+		location = value
+	}
+
+	archiveFile, heading, _ := strings.Cut(location, "::")
+	name := filepath.Base(file)
+	archiveFile = strings.TrimSpace(strings.ReplaceAll(archiveFile, "%s", name))
+	heading = strings.TrimSpace(strings.ReplaceAll(heading, "%s", name))
+	switch {
+	case archiveFile == "":
+		archiveFile = file
+	case !filepath.IsAbs(archiveFile):
+		archiveFile = filepath.Join(filepath.Dir(file), archiveFile)
+	}
+	if heading != "" && !edit.IsHeadline(heading) {
+		heading = "* " + heading
+	}
+	return archiveFile, heading
+}
+
+// archiveContext adds the ARCHIVE_* properties describing the origin of the
+// subtree that starts at lines[idx] to a copy of subtree.
+func (s *Service) archiveContext(file string, lines []string, idx int, subtree []string) []string {
+	content := strings.Join(lines, "\n")
+	items := parser.ParseStringWithOptions(content, file, s.ParseOptions)
+	path := outlinePath(items, idx)
+	if len(path) == 0 {
+		return subtree
+	}
+	it := path[len(path)-1]
+
+	var olpath []string
+	for _, ancestor := range path[:len(path)-1] {
+		olpath = append(olpath, ancestor.Title)
+	}
+	category, ok := inheritedProperty(items, idx, "CATEGORY")
+	if !ok {
+		category = parser.FileOptions(content, s.ParseOptions).Category
+	}
+	if category == "" {
+		category = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	subtree = append([]string(nil), subtree...)
+	properties := []struct{ key, value string }{
+		{"ARCHIVE_TIME", now().Format("2006-01-02 Mon 15:04")},
+		{"ARCHIVE_FILE", absPath(file)},
+		{"ARCHIVE_OLPATH", strings.Join(olpath, "/")},
+		{"ARCHIVE_CATEGORY", category},
+		{"ARCHIVE_TODO", it.Status},
+	}
+	for _, p := range properties {
+		if p.value != "" {
+			subtree = edit.SetProperty(subtree, 0, p.key, p.value)
+		}
+	}
+	return subtree
+}
+
+// outlinePath returns the headline at lines index idx preceded by its
+// ancestors, outermost first.
+func outlinePath(items []*item.Item, idx int) []*item.Item {
+	var path []*item.Item
+	for _, it := range items {
+		if it.LineNumber-1 > idx {
+			break
+		}
+		for len(path) > 0 && path[len(path)-1].Level >= it.Level {
+			path = path[:len(path)-1]
+		}
+		path = append(path, it)
+	}
+	if len(path) == 0 || path[len(path)-1].LineNumber-1 != idx {
+		return nil
+	}
+	return path
+}
+
+// inheritedProperty looks key up on the headline at idx and its ancestors.
+func inheritedProperty(items []*item.Item, idx int, key string) (string, bool) {
+	path := outlinePath(items, idx)
+	for i := len(path) - 1; i >= 0; i-- {
+		if value, ok := path[i].Properties[key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// findHeadlineLine returns the index of the line equal to headline, ignoring
+// trailing whitespace, or -1.
+func findHeadlineLine(lines []string, headline string) int {
+	for i, line := range lines {
+		if strings.TrimRight(line, " \t") == headline {
+			return i
+		}
+	}
+	return -1
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
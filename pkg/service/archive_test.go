@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_Archive(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.Local))
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	content := `#+CATEGORY: work
+* Projects
+** DONE Website
+CLOSED: [2026-01-02 Fri 09:00]
+Notes
+*** DONE Mockups
+* TODO Other
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.ParseOptions.ArchiveLocation = "%s_archive::* From %s"
+
+	result, err := svc.Archive(file+":3", true, EditOptions{})
+	if err != nil {
+		t.Fatalf("Archive dry run failed: %v", err)
+	}
+	archive := file + "_archive"
+	if result.File != archive || result.Heading != "* From tasks.org" {
+		t.Errorf("Unexpected location: %s", result.Location())
+	}
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Error("Dry run created the archive file")
+	}
+
+	if _, err := svc.Archive(file+":3", false, EditOptions{}); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "#+CATEGORY: work\n* Projects\n* TODO Other\n" {
+		t.Errorf("Unexpected source: %q", data)
+	}
+	data, _ = os.ReadFile(archive)
+	expected := `#    -*- mode: org -*-
+
+
+Archived entries from file ` + file + `
+
+* From tasks.org
+** DONE Website
+CLOSED: [2026-01-02 Fri 09:00]
+:PROPERTIES:
+:ARCHIVE_TIME: 2026-01-05 Mon 10:00
+:ARCHIVE_FILE: ` + file + `
+:ARCHIVE_OLPATH: Projects
+:ARCHIVE_CATEGORY: work
+:ARCHIVE_TODO: DONE
+:END:
+Notes
+*** DONE Mockups
+`
+	if string(data) != expected {
+		t.Errorf("Unexpected archive:\ngot  %q\nwant %q", data, expected)
+	}
+}
+
+func TestService_ArchiveLocationProperty(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := `#+ARCHIVE: other.org::
+* Projects
+:PROPERTIES:
+:ARCHIVE: ::* Archive
+:END:
+** DONE Task
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	if _, err := svc.Archive(file+":6", false, EditOptions{}); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	prefix := "#+ARCHIVE: other.org::\n* Projects\n:PROPERTIES:\n:ARCHIVE: ::* Archive\n:END:\n* Archive\n** DONE Task\n"
+	if len(data) < len(prefix) || string(data[:len(prefix)]) != prefix {
+		t.Errorf("Expected the task below * Archive in the same file, got %q", data)
+	}
+}
+
+func TestService_ArchiveDone(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := `* DONE Old
+CLOSED: [2026-01-02 Fri 09:00]
+** DONE Old child
+CLOSED: [2026-01-01 Thu 09:00]
+* DONE Recent
+CLOSED: [2026-02-25 Wed 09:00]
+* TODO Open
+* DONE Older
+CLOSED: [2026-01-10 Sat 09:00]
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	results, err := svc.ArchiveDone(30*24*time.Hour, true)
+	if err != nil {
+		t.Fatalf("ArchiveDone dry run failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Item.Title != "Old" || results[1].Item.Title != "Older" {
+		t.Fatalf("Unexpected candidates: %+v", results)
+	}
+
+	if _, err := svc.ArchiveDone(30*24*time.Hour, false); err != nil {
+		t.Fatalf("ArchiveDone failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* DONE Recent\nCLOSED: [2026-02-25 Wed 09:00]\n* TODO Open\n" {
+		t.Errorf("Unexpected source: %q", data)
+	}
+	items, _ := NewService([]string{file}, file).LoadItems()
+	if len(items) != 2 {
+		t.Errorf("Expected 2 remaining items, got %d", len(items))
+	}
+}
+
+func TestService_ArchiveDoneInFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := `#+ARCHIVE: ::* Archive
+* DONE Old
+CLOSED: [2026-01-02 Fri 09:00]
+* DONE Kept aside                                                  :ARCHIVE:
+CLOSED: [2026-01-03 Sat 09:00]
+* Archive
+** DONE Archived before
+CLOSED: [2025-12-01 Mon 09:00]
+:PROPERTIES:
+:ARCHIVE_TIME: 2025-12-31 Wed 10:00
+:END:
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	results, err := svc.ArchiveDone(30*24*time.Hour, false)
+	if err != nil {
+		t.Fatalf("ArchiveDone failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Item.Title != "Old" {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), ":ARCHIVE_TIME: 2025-12-31 Wed 10:00") {
+		t.Errorf("The entry archived before was archived again:\n%s", data)
+	}
+
+	// Running again finds nothing left to archive.
+	if results, err := svc.ArchiveDone(30*24*time.Hour, true); err != nil || len(results) != 0 {
+		t.Errorf("Unexpected second run: %+v, %v", results, err)
+	}
+}
+
+func TestService_ArchiveDoneHeadingAbove(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := `#+ARCHIVE: ::* Archive
+* Archive
+* DONE First
+CLOSED: [2026-01-02 Fri 09:00]
+First body
+* TODO Open
+* DONE Second
+CLOSED: [2026-01-03 Sat 09:00]
+Second body
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	results, err := svc.ArchiveDone(30*24*time.Hour, false)
+	if err != nil {
+		t.Fatalf("ArchiveDone failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Item.Title != "First" || results[1].Item.Title != "Second" {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+
+	items, _ := NewService([]string{file}, file).LoadItems()
+	var titles []string
+	for _, it := range items {
+		titles = append(titles, fmt.Sprintf("%d %s", it.Level, it.Title))
+	}
+	if got := strings.Join(titles, ", "); got != "1 Archive, 2 First, 2 Second, 1 Open" {
+		t.Errorf("Unexpected outline %q", got)
+	}
+	data, _ := os.ReadFile(file)
+	for _, body := range []string{"First body", "Second body"} {
+		if strings.Count(string(data), body) != 1 {
+			t.Errorf("Expected %q once:\n%s", body, data)
+		}
+	}
+}
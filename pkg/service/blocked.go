@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// ErrBlocked is returned when a task cannot be completed because of open
+// dependencies.
+var ErrBlocked = errors.New("task is blocked")
+
+// blockerIDsRegex matches the ids(...) finder of org-edna's :BLOCKER:
+// property.
+var blockerIDsRegex = regexp.MustCompile(`ids\(([^)]*)\)`)
+
+// setBlockedBy records on every open task of items the open tasks that keep
+// it from being completed, the way org-enforce-todo-dependencies and org-edna
+// see it: open descendants, open earlier siblings below an ancestor with
+// ":ORDERED: t", and the tasks listed by ids(...) in its :BLOCKER: property.
+// Items must be grouped by file in file order.
+func setBlockedBy(items []*item.Item) {
+	byID := map[string]*item.Item{}
+	for _, it := range items {
+		it.BlockedBy = nil
+		if it.CustomID != "" {
+			byID[it.CustomID] = it
+		}
+	}
+	for _, it := range items {
+		if it.ID != "" {
+			byID[it.ID] = it
+		}
+	}
+
+	for start := 0; start < len(items); {
+		end := start + 1
+		for end < len(items) && items[end].FilePath == items[start].FilePath {
+			end++
+		}
+		setFileBlockedBy(items[start:end], byID)
+		start = end
+	}
+}
+
+func setFileBlockedBy(items []*item.Item, byID map[string]*item.Item) {
+	// path holds the indices of the current headline and its ancestors.
+	var path []int
+	for i, it := range items {
+		for len(path) > 0 && items[path[len(path)-1]].Level >= it.Level {
+			path = path[:len(path)-1]
+		}
+		path = append(path, i)
+		if !isOpen(it) {
+			continue
+		}
+
+		var blockers []*item.Item
+		for j := i + 1; j < len(items) && items[j].Level > it.Level; j++ {
+			if isOpen(items[j]) {
+				blockers = append(blockers, items[j])
+			}
+		}
+		for k := len(path) - 1; k > 0; k-- {
+			parent, child := items[path[k-1]], path[k]
+			if !isOrdered(parent) {
+				continue
+			}
+			for j := path[k-1] + 1; j < child; j++ {
+				if items[j].Level == items[child].Level && isOpen(items[j]) {
+					blockers = append(blockers, items[j])
+				}
+			}
+		}
+		for _, id := range blockerIDs(it.Properties["BLOCKER"]) {
+			if blocker, ok := byID[id]; ok && blocker != it && isOpen(blocker) {
+				blockers = append(blockers, blocker)
+			}
+		}
+
+		seen := map[*item.Item]bool{}
+		for _, b := range blockers {
+			if !seen[b] {
+				seen[b] = true
+				it.BlockedBy = append(it.BlockedBy, itemRef(b))
+			}
+		}
+	}
+}
+
+func isOpen(it *item.Item) bool {
+	return it.Status != "" && !it.Done
+}
+
+// isOrdered reports whether the children of it have to be completed in
+// order. Like Org, any value other than "nil" counts.
+func isOrdered(it *item.Item) bool {
+	value, ok := it.Properties["ORDERED"]
+	value = strings.TrimSpace(value)
+	return ok && value != "" && value != "nil"
+}
+
+// blockerIDs returns the IDs named by the ids(...) finders of a :BLOCKER:
+// property. IDs may be quoted and carry an "id:" prefix.
+func blockerIDs(value string) []string {
+	var ids []string
+	for _, m := range blockerIDsRegex.FindAllStringSubmatch(value, -1) {
+		for _, id := range strings.Fields(m[1]) {
+			id, _ = normalizeID(strings.Trim(id, `"`))
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// itemRef returns the most stable reference to it that Resolve accepts.
+func itemRef(it *item.Item) string {
+	switch {
+	case it.ID != "":
+		return it.ID
+	case it.CustomID != "":
+		return "#" + it.CustomID
+	}
+	return fmt.Sprintf("%s:%d", it.FilePath, it.LineNumber)
+}
+
+// checkBlocked fails with ErrBlocked when the headline at idx of lines, which
+// belong to file, has open dependencies. The other configured files are read
+// to resolve :BLOCKER: IDs.
+func (s *Service) checkBlocked(file string, lines []string, idx int) error {
+	items := parser.ParseStringWithOptions(strings.Join(lines, "\n"), file, s.ParseOptions)
+	all := items
+	last, skip := "", false
+	loaded, _ := s.LoadItems()
+	for _, it := range loaded {
+		if it.FilePath != last {
+			last, skip = it.FilePath, sameFile(it.FilePath, file)
+		}
+		if !skip {
+			all = append(all, it)
+		}
+	}
+	setBlockedBy(all)
+	for _, it := range items {
+		if it.LineNumber-1 == idx && len(it.BlockedBy) > 0 {
+			return fmt.Errorf("%w by %s", ErrBlocked, strings.Join(it.BlockedBy, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestService_BlockedBy(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	other := filepath.Join(dir, "other.org")
+	content := `* TODO Project
+:PROPERTIES:
+:ORDERED: t
+:END:
+** DONE Step 1
+** TODO Step 2
+** TODO Step 3
+*** TODO Step 3a
+** Notes
+* TODO Release
+:PROPERTIES:
+:BLOCKER: ids(review-1 "id:missing") previous-sibling
+:END:
+* TODO Unordered
+** TODO Child A
+** TODO Child B
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(other, []byte("* TODO Review\n:PROPERTIES:\n:CUSTOM_ID: review-1\n:END:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService([]string{file, other}, file)
+	items, err := svc.LoadItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, it := range items {
+		got[it.Title] = it.BlockedBy
+	}
+
+	want := map[string][]string{
+		"Project":   {file + ":6", file + ":7", file + ":8"},
+		"Step 1":    nil,
+		"Step 2":    nil,
+		"Step 3":    {file + ":8", file + ":6"},
+		"Step 3a":   {file + ":6"},
+		"Notes":     nil,
+		"Release":   {"#review-1"},
+		"Unordered": {file + ":15", file + ":16"},
+		"Child A":   nil,
+		"Child B":   nil,
+		"Review":    nil,
+	}
+	for title, w := range want {
+		if !reflect.DeepEqual(got[title], w) {
+			t.Errorf("BlockedBy of %q = %v, want %v", title, got[title], w)
+		}
+	}
+
+	todos, err := svc.ListTodos(ListOptions{Actionable: true, Sort: []string{"file"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, it := range todos {
+		titles = append(titles, it.Title)
+	}
+	if strings.Join(titles, ",") != "Review,Step 1,Step 2,Child A,Child B" {
+		t.Errorf("actionable tasks = %v", titles)
+	}
+
+	todos, err = svc.ListTodos(ListOptions{Blocked: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 5 {
+		t.Errorf("expected 5 blocked tasks, got %d", len(todos))
+	}
+}
+
+func TestService_MarkDoneBlocked(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "* TODO Parent\n** TODO Child\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	err := svc.MarkDone(file+":1", EditOptions{})
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected ErrBlocked, got %v", err)
+	}
+	if !strings.Contains(err.Error(), file+":2") {
+		t.Errorf("expected the blocking child in the error, got %v", err)
+	}
+	if _, err := svc.SetState(file+":1", "DONE", "", EditOptions{}); !errors.Is(err, ErrBlocked) {
+		t.Errorf("expected SetState to be blocked too, got %v", err)
+	}
+	if _, err := svc.SetState(file+":1", "WAITING", "", EditOptions{}); err != nil {
+		t.Errorf("a blocked task may still change to an open state: %v", err)
+	}
+
+	if err := svc.MarkDone(file+":2", EditOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.MarkDone(file+":1", EditOptions{}); err != nil {
+		t.Errorf("expected the parent to be completable once the child is done: %v", err)
+	}
+
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.MarkDone(file+":1", EditOptions{Force: true}); err != nil {
+		t.Fatalf("Force should complete a blocked task: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.HasPrefix(string(data), "* DONE Parent\n") {
+		t.Errorf("unexpected content: %s", data)
+	}
+}
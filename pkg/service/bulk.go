@@ -0,0 +1,321 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/agenda"
+	"github.com/garaemon/org-agenda-cli/pkg/dateinput"
+	"github.com/garaemon/org-agenda-cli/pkg/diff"
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/journal"
+	"github.com/garaemon/org-agenda-cli/pkg/orgfile"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// BulkOptions describes what Bulk does to every matching task. State is a
+// TODO keyword or fast-access key and Schedule date input as understood by
+// Reschedule; AddTags and RemoveTags edit the tags. Archive moves the tasks
+// to their archive location and cannot be combined with the other changes.
+// With DryRun nothing is written.
+type BulkOptions struct {
+	State      string
+	Schedule   string
+	AddTags    []string
+	RemoveTags []string
+	Archive    bool
+	DryRun     bool
+}
+
+// BulkChange is the change Bulk made, or would make, to a file. Before is
+// nil when the file is created.
+type BulkChange struct {
+	File   string
+	Before []byte
+	After  []byte
+}
+
+// Diff renders the change as a unified diff.
+func (c BulkChange) Diff() string {
+	from := c.File
+	if c.Before == nil {
+		from = "/dev/null"
+	}
+	return diff.Unified(from, c.File, splitContent(c.Before), splitContent(c.After), 3)
+}
+
+// BulkResult lists the tasks Bulk changed and the files it touched.
+type BulkResult struct {
+	Items   []*item.Item
+	Changes []BulkChange
+}
+
+// Bulk applies opts to every task of the configured files that matches the
+// query, in the syntax of agenda.ParseMatch. All files involved are locked
+// for the whole operation and nothing is written unless every change could be
+// computed. The changes are journaled as a single operation. Dependencies
+// between tasks are not enforced.
+func (s *Service) Bulk(query string, opts BulkOptions) (*BulkResult, error) {
+	match, err := agenda.ParseMatch(query)
+	if err != nil {
+		return nil, err
+	}
+	edits := opts.State != "" || opts.Schedule != "" || len(opts.AddTags) > 0 || len(opts.RemoveTags) > 0
+	switch {
+	case opts.Archive && edits:
+		return nil, fmt.Errorf("archiving cannot be combined with other changes")
+	case !opts.Archive && !edits:
+		return nil, fmt.Errorf("no change requested")
+	}
+	for _, tag := range append(append([]string{}, opts.AddTags...), opts.RemoveTags...) {
+		if !edit.ValidTag(tag) {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+	ts := ""
+	if opts.Schedule != "" {
+		d, err := dateinput.Parse(opts.Schedule, now())
+		if err != nil {
+			return nil, err
+		}
+		ts = d.Timestamp()
+	}
+
+	plan := &bulkPlan{s: s, match: match, opts: opts, ts: ts}
+	return s.execute("bulk "+query, plan)
+}
+
+// execute computes the changes of plan and, unless it is a dry run, computes
+// them again with all files involved locked and writes them, journaled as
+// the single operation op.
+func (s *Service) execute(op string, plan *bulkPlan) (*BulkResult, error) {
+	result, err := plan.run()
+	if err != nil || plan.opts.DryRun {
+		return result, err
+	}
+
+	// Lock every configured file and the archive files found above, then
+	// compute the changes again from the locked content.
+	paths := append([]string{}, s.OrgFiles...)
+	for _, c := range result.Changes {
+		paths = append(paths, c.File)
+	}
+	unlock, err := orgfile.LockAll(paths...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	plan.locked = map[string]bool{}
+	for _, path := range paths {
+		plan.locked[absPath(path)] = true
+	}
+	if result, err = plan.run(); err != nil {
+		return nil, err
+	}
+
+	var changes []journal.Change
+	for _, c := range result.Changes {
+		if err := orgfile.WriteFile(c.File, c.After); err != nil {
+			// Record what was written so that it can still be undone.
+			s.record(op, changes...)
+			return nil, err
+		}
+		changes = append(changes, journal.NewChange(c.File, c.Before, c.After))
+	}
+	if len(changes) > 0 {
+		s.record(op, changes...)
+	}
+	for _, a := range plan.archived {
+		s.reindexMoved(a.subtree, a.dest)
+	}
+	return result, nil
+}
+
+// bulkPlan computes the changes of one Bulk run in memory.
+type bulkPlan struct {
+	s      *Service
+	match  func(*item.Item) bool
+	opts   BulkOptions
+	ts     string
+	locked map[string]bool
+
+	files    []*bulkFile
+	byPath   map[string]*bulkFile
+	archived []archivedSubtree
+}
+
+// bulkFile is a file as read and as changed by the plan.
+type bulkFile struct {
+	path   string
+	before []byte
+	lines  []string
+}
+
+// archivedSubtree is the subtree of item, planned to move from source to
+// below heading of dest.
+type archivedSubtree struct {
+	item                  *item.Item
+	source, dest, heading string
+	subtree               []string
+}
+
+// run computes the changes from the current content of the files. All
+// subtrees to archive are removed from their files before any is inserted
+// into its archive location, so that positions found in one file stay valid
+// when it is the archive location of another.
+func (p *bulkPlan) run() (*BulkResult, error) {
+	p.files, p.byPath, p.archived = nil, nil, nil
+	result := &BulkResult{}
+	for _, path := range p.s.OrgFiles {
+		f, err := p.file(path)
+		if err != nil {
+			return nil, err
+		}
+		if f.before == nil {
+			continue
+		}
+		items, err := p.apply(f)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, items...)
+	}
+	for _, a := range p.archived {
+		f, err := p.file(a.dest)
+		if err != nil {
+			return nil, err
+		}
+		f.lines, _ = insertArchived(f.lines, !sameFile(a.source, a.dest), a.source, a.heading, a.subtree)
+	}
+
+	// Archive files come first so that they are written before the subtrees
+	// are removed from their sources.
+	dests := map[string]bool{}
+	for _, a := range p.archived {
+		dests[absPath(a.dest)] = true
+	}
+	files := append([]*bulkFile{}, p.files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return dests[absPath(files[i].path)] && !dests[absPath(files[j].path)]
+	})
+
+	for _, f := range files {
+		after := []byte(strings.Join(f.lines, "\n"))
+		if p.opts.Archive {
+			after = []byte(edit.JoinLines(f.lines))
+		}
+		if string(after) == string(f.before) {
+			continue
+		}
+		if p.locked != nil && !p.locked[absPath(f.path)] {
+			return nil, fmt.Errorf("%w: %s was not part of the locked files", ErrConflict, f.path)
+		}
+		result.Changes = append(result.Changes, BulkChange{File: f.path, Before: f.before, After: after})
+	}
+	return result, nil
+}
+
+// file returns the file at path, reading it on first use. A missing file has
+// nil content.
+func (p *bulkPlan) file(path string) (*bulkFile, error) {
+	if p.byPath == nil {
+		p.byPath = map[string]*bulkFile{}
+	}
+	key := absPath(path)
+	if f, ok := p.byPath[key]; ok {
+		return f, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	f := &bulkFile{path: path, before: content}
+	if content != nil {
+		f.lines = strings.Split(string(content), "\n")
+		if p.opts.Archive {
+			f.lines = trimTrailingEmpty(f.lines)
+		}
+	}
+	p.byPath[key] = f
+	p.files = append(p.files, f)
+	return f, nil
+}
+
+// apply changes the matching tasks of f, from the bottom up so that the
+// positions of the remaining ones stay valid, and returns them.
+func (p *bulkPlan) apply(f *bulkFile) ([]*item.Item, error) {
+	content := string(f.before)
+	lines := strings.Split(content, "\n")
+	var matched []*item.Item
+	archivedLevel := 0
+	for _, it := range parser.ParseStringWithOptions(content, f.path, p.s.ParseOptions) {
+		if p.opts.Archive {
+			// Descendants of an archived task move along with it.
+			if archivedLevel > 0 && it.Level > archivedLevel {
+				continue
+			}
+			archivedLevel = 0
+		}
+		if it.Status == "" || !p.match(it) {
+			continue
+		}
+		if p.opts.Archive && p.s.isArchived(f.path, lines, it.LineNumber-1) {
+			continue
+		}
+		matched = append(matched, it)
+		archivedLevel = it.Level
+	}
+
+	if p.opts.Archive {
+		original := append([]string(nil), f.lines...)
+		archived := make([]archivedSubtree, len(matched))
+		for i := len(matched) - 1; i >= 0; i-- {
+			idx := matched[i].LineNumber - 1
+			end := edit.SubtreeEnd(f.lines, idx)
+			dest, heading := p.s.archiveLocation(f.path, original, idx)
+			subtree := p.s.archiveContext(f.path, original, idx, append([]string(nil), f.lines[idx:end]...))
+			archived[i] = archivedSubtree{item: matched[i], source: f.path, dest: dest, heading: heading, subtree: subtree}
+			f.lines = append(f.lines[:idx], f.lines[end:]...)
+		}
+		p.archived = append(p.archived, archived...)
+		return matched, nil
+	}
+
+	fileOpts := parser.FileOptions(content, p.s.ParseOptions)
+	for i := len(matched) - 1; i >= 0; i-- {
+		it := matched[i]
+		idx := it.LineNumber - 1
+		if p.opts.State != "" {
+			to, ok := fileOpts.Keywords.Find(p.opts.State)
+			if !ok {
+				return nil, fmt.Errorf("unknown TODO keyword %q in %s", p.opts.State, f.path)
+			}
+			if to.Name != it.Status {
+				f.lines = changeState(f.lines, idx, it.Status, to, "", fileOpts)
+			}
+		}
+		if p.ts != "" {
+			f.lines, _ = p.s.changePlanning(f.lines, idx, "SCHEDULED", p.ts, "")
+		}
+		if len(p.opts.AddTags) > 0 || len(p.opts.RemoveTags) > 0 {
+			tags := applyTagChange(it.Tags, p.opts.AddTags, p.opts.RemoveTags)
+			if strings.Join(tags, ":") != strings.Join(it.Tags, ":") {
+				f.lines[idx] = edit.SetTags(f.lines[idx], tags, p.s.TagsColumn)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// splitContent returns the lines of content without the empty element a
+// trailing newline leaves behind.
+func splitContent(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return trimTrailingEmpty(strings.Split(string(content), "\n"))
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_Bulk(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.Local))
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work.org")
+	home := filepath.Join(dir, "home.org")
+	workContent := "* TODO Report :work:\n* TODO Slides :work:talk:\n* DONE Old :work:\n"
+	homeContent := "* TODO Groceries :home:\n* TODO Taxes :work:\n"
+	for path, content := range map[string]string{work: workContent, home: homeContent} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := NewService([]string{work, home}, work)
+	svc.JournalPath = filepath.Join(dir, "journal.jsonl")
+	svc.TagsColumn = 0
+
+	result, err := svc.Bulk("work-talk/TODO", BulkOptions{State: "DONE", DryRun: true})
+	if err != nil {
+		t.Fatalf("Bulk dry run failed: %v", err)
+	}
+	if len(result.Items) != 2 || len(result.Changes) != 2 {
+		t.Fatalf("Expected 2 items in 2 files, got %d items in %d files", len(result.Items), len(result.Changes))
+	}
+	if d := result.Changes[0].Diff(); !strings.Contains(d, "-* TODO Report :work:\n+* DONE Report :work:\n+CLOSED: [2026-01-05 Mon 10:00]\n") {
+		t.Errorf("Unexpected diff:\n%s", d)
+	}
+	if data, _ := os.ReadFile(work); string(data) != workContent {
+		t.Error("Dry run modified the file")
+	}
+
+	if _, err := svc.Bulk("work-talk/TODO", BulkOptions{State: "DONE", Schedule: "2026-01-10", AddTags: []string{"q1"}}); err != nil {
+		t.Fatalf("Bulk failed: %v", err)
+	}
+	want := "* DONE Report :work:q1:\nCLOSED: [2026-01-05 Mon 10:00] SCHEDULED: <2026-01-10 Sat>\n* TODO Slides :work:talk:\n* DONE Old :work:\n"
+	if data, _ := os.ReadFile(work); string(data) != want {
+		t.Errorf("Unexpected work.org:\ngot  %q\nwant %q", data, want)
+	}
+	want = "* TODO Groceries :home:\n* DONE Taxes :work:q1:\nCLOSED: [2026-01-05 Mon 10:00] SCHEDULED: <2026-01-10 Sat>\n"
+	if data, _ := os.ReadFile(home); string(data) != want {
+		t.Errorf("Unexpected home.org:\ngot  %q\nwant %q", data, want)
+	}
+
+	// Both files are restored by a single undo.
+	if entries, err := svc.History(); err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 journal entry, got %d (%v)", len(entries), err)
+	}
+	if _, err := svc.Undo(1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	for path, content := range map[string]string{work: workContent, home: homeContent} {
+		if data, _ := os.ReadFile(path); string(data) != content {
+			t.Errorf("Undo left %s as %q", path, data)
+		}
+	}
+
+	for _, opts := range []BulkOptions{{}, {Archive: true, State: "DONE"}, {AddTags: []string{"a b"}}} {
+		if _, err := svc.Bulk("work", opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+	if _, err := svc.Bulk("OWNER>", BulkOptions{State: "DONE"}); err == nil {
+		t.Error("Expected an error for an invalid query")
+	}
+}
+
+func TestService_BulkArchive(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.Local))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "* DONE First\n** DONE Child\n* TODO Open\n* DONE Second\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.ParseOptions.ArchiveLocation = "%s_archive::"
+	archive := file + "_archive"
+
+	result, err := svc.Bulk("/DONE", BulkOptions{Archive: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("Bulk dry run failed: %v", err)
+	}
+	if len(result.Items) != 2 || len(result.Changes) != 2 || result.Changes[0].File != archive {
+		t.Fatalf("Unexpected dry run result: %d items, %d changes", len(result.Items), len(result.Changes))
+	}
+	if !strings.HasPrefix(result.Changes[0].Diff(), "--- /dev/null\n") {
+		t.Errorf("Expected a diff creating the archive, got:\n%s", result.Changes[0].Diff())
+	}
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Error("Dry run created the archive file")
+	}
+
+	if _, err := svc.Bulk("/DONE", BulkOptions{Archive: true}); err != nil {
+		t.Fatalf("Bulk failed: %v", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "* TODO Open\n" {
+		t.Errorf("Unexpected source: %q", data)
+	}
+	data, _ := os.ReadFile(archive)
+	for _, want := range []string{"* DONE First\n", "** DONE Child\n", "* DONE Second\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Archive is missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Index(string(data), "First") > strings.Index(string(data), "Second") {
+		t.Errorf("Archived subtrees are out of order:\n%s", data)
+	}
+}
+
+func TestService_BulkArchiveInFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.Local))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "* DONE First\n* DONE Aside :ARCHIVE:\n* Archive\n** DONE Before\n:PROPERTIES:\n:ARCHIVE_TIME: 2025-12-31 Wed 10:00\n:END:\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.ParseOptions.ArchiveLocation = "::* Archive"
+
+	result, err := svc.Bulk("/DONE", BulkOptions{Archive: true})
+	if err != nil {
+		t.Fatalf("Bulk failed: %v", err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Title != "First" {
+		t.Fatalf("Unexpected items: %+v", result.Items)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), ":ARCHIVE_TIME: 2025-12-31 Wed 10:00") || !strings.Contains(string(data), "* DONE Aside :ARCHIVE:\n") {
+		t.Errorf("Archived entries were archived again:\n%s", data)
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
+)
+
+// CaptureInput returns the input for expanding a capture template for
+// content captured at, with the clocked-in task and the shell escapes
+// setting filled in.
+func (s *Service) CaptureInput(content string, at time.Time) capture.Input {
+	in := capture.Input{Content: content, Time: at, Shell: s.CaptureShell}
+	if t := s.ClockedIn(); t != nil {
+		in.Clocked = t.Item.Title
+		in.ClockedLink = t.Link()
+	}
+	return in
+}
+
+// CaptureTemplate returns the capture template with key. An empty key
+// selects the only template.
+func (s *Service) CaptureTemplate(key string) (capture.Template, error) {
+	if key == "" && len(s.CaptureTemplates) == 1 {
+		return s.CaptureTemplates[0], nil
+	}
+	if key == "" {
+		return capture.Template{}, fmt.Errorf("several capture templates are configured, select one")
+	}
+	for _, t := range s.CaptureTemplates {
+		if t.Key == key {
+			return t, nil
+		}
+	}
+	return capture.Template{}, fmt.Errorf("unknown capture template %q", key)
+}
+
+// ResolveCaptureTarget returns tmpl with the file it captures to at: the
+// file name with its date placeholders expanded, or for capture.TargetID
+// the file the headline with the ID is found in among the configured files.
+func (s *Service) ResolveCaptureTarget(tmpl capture.Template, at time.Time) (capture.Template, error) {
+	tmpl.File = capture.FormatAt(tmpl.File, "", at)
+	if tmpl.Target == capture.TargetID && tmpl.ID != "" {
+		t, err := s.findByID(tmpl.ID)
+		if err != nil {
+			return capture.Template{}, err
+		}
+		tmpl.File = t.FilePath
+	}
+	return tmpl, tmpl.Validate()
+}
+
+// Capture inserts entry into the file of tmpl at its target, as
+// Template.Insert does with the capture time at, journals the change and
+// returns the file. The file is resolved by ResolveCaptureTarget.
+func (s *Service) Capture(tmpl capture.Template, entry string, at time.Time) (string, error) {
+	tmpl, err := s.ResolveCaptureTarget(tmpl, at)
+	if err != nil {
+		return "", err
+	}
+	err = s.update("capture "+tmpl.File, tmpl.File, func(content []byte) ([]byte, error) {
+		output, err := tmpl.Insert(string(content), entry, at)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(output), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return tmpl.File, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
+)
+
+func TestService_CaptureID(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	inbox := filepath.Join(dir, "inbox.org")
+	projects := filepath.Join(dir, "projects.org")
+	if err := os.WriteFile(inbox, []byte("* Inbox\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projects, []byte("* Projects\n** Website\n:PROPERTIES:\n:ID: website\n:END:\n*** Earlier\n** Other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{inbox, projects}, inbox)
+	svc.IDIndexPath = filepath.Join(dir, "index.json")
+
+	tmpl := capture.Template{Type: capture.TypeEntry, Target: capture.TargetID, ID: "id:website"}
+	file, err := svc.Capture(tmpl, "* Meeting\n", time.Now())
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if file != projects {
+		t.Errorf("Captured to %s", file)
+	}
+	tmpl = capture.Template{Type: capture.TypePlain, Target: capture.TargetID, ID: "website"}
+	if _, err := svc.Capture(tmpl, "Kickoff on Monday.\n", time.Now()); err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	want := "* Projects\n** Website\n:PROPERTIES:\n:ID: website\n:END:\nKickoff on Monday.\n*** Earlier\n*** Meeting\n** Other\n"
+	if data, _ := os.ReadFile(projects); string(data) != want {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, want)
+	}
+
+	tmpl.ID = "missing"
+	if _, err := svc.Capture(tmpl, "Lost\n", time.Now()); err == nil || !strings.Contains(err.Error(), `no task with ID "missing" found`) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
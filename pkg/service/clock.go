@@ -0,0 +1,53 @@
+package service
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// openClockRegex matches a CLOCK line that has been started but not stopped.
+var openClockRegex = regexp.MustCompile(`^\s*CLOCK:\s*\[[^\]]+\]\s*$`)
+
+// ClockedIn returns the task with a running clock, i.e. an open CLOCK line,
+// or nil when no clock runs.
+func (s *Service) ClockedIn() *Target {
+	for _, file := range s.OrgFiles {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+		for i, line := range lines {
+			if !openClockRegex.MatchString(line) {
+				continue
+			}
+			var owner *Target
+			for _, it := range parser.ParseStringWithOptions(string(content), file, s.ParseOptions) {
+				if it.LineNumber > i+1 {
+					break
+				}
+				owner = &Target{FilePath: file, Line: it.LineNumber, Item: it}
+			}
+			if owner != nil {
+				return owner
+			}
+		}
+	}
+	return nil
+}
+
+// Link returns an Org link to the task: by ID when it has one, by its
+// headline otherwise.
+func (t *Target) Link() string {
+	if t.Item != nil && t.Item.ID != "" {
+		return "id:" + t.Item.ID
+	}
+	title := ""
+	if t.Item != nil {
+		title = t.Item.Title
+	}
+	return "file:" + t.FilePath + "::*" + title
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestService_ClockedIn(t *testing.T) {
+	dir := t.TempDir()
+	done := filepath.Join(dir, "done.org")
+	work := filepath.Join(dir, "work.org")
+	files := map[string]string{
+		done: "* DONE Old\n:LOGBOOK:\nCLOCK: [2026-01-02 Fri 09:00]--[2026-01-02 Fri 10:00] =>  1:00\n:END:\n",
+		work: "* Project\n** TODO Review\n:PROPERTIES:\n:ID: review-1\n:END:\n:LOGBOOK:\nCLOCK: [2026-01-05 Mon 09:00]\n:END:\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := NewService([]string{done, work}, done)
+
+	clock := svc.ClockedIn()
+	if clock == nil || clock.FilePath != work || clock.Line != 2 {
+		t.Fatalf("ClockedIn() = %+v", clock)
+	}
+	if link := clock.Link(); link != "id:review-1" {
+		t.Errorf("Link() = %q", link)
+	}
+	in := svc.CaptureInput("Note", time.Now())
+	if in.Clocked != "Review" || in.ClockedLink != "id:review-1" || in.Shell {
+		t.Errorf("CaptureInput() = %+v", in)
+	}
+
+	if NewService([]string{done}, done).ClockedIn() != nil {
+		t.Error("Expected no running clock")
+	}
+	clock.Item.ID = ""
+	if link := clock.Link(); link != "file:"+work+"::*Review" {
+		t.Errorf("Link() without ID = %q", link)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// ErrConflict is returned when a guarded edit finds that the task changed
+// since it was listed.
+var ErrConflict = errors.New("conflict")
+
+// EditOptions guards a modification against a file that changed since the
+// task was listed. Fingerprint is the value reported by the listing; when it
+// no longer matches the headline at the referenced position the edit is
+// rejected, unless Relocate is set and the headline can be found elsewhere in
+// the same file. Force completes a task even when it is blocked.
+type EditOptions struct {
+	Fingerprint string
+	Relocate    bool
+	Force       bool
+}
+
+// Fingerprint identifies a headline at a point in time: a hash of the
+// headline text followed by the modification time of its file. Only the hash
+// is compared when guarding edits; the time tells clients how fresh it is.
+func Fingerprint(headline string, modTime time.Time) string {
+	return fmt.Sprintf("%s-%d", headlineHash(headline), modTime.Unix())
+}
+
+func headlineHash(headline string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(headline, " \t\r")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprintHash extracts the headline hash from a fingerprint.
+func fingerprintHash(fingerprint string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(fingerprint), "-")
+	return hash
+}
+
+// setFingerprints fills in the fingerprint of every item parsed from content.
+func setFingerprints(items []*item.Item, content string, modTime time.Time) {
+	lines := strings.Split(content, "\n")
+	for _, it := range items {
+		if it.LineNumber >= 1 && it.LineNumber <= len(lines) {
+			it.Fingerprint = Fingerprint(lines[it.LineNumber-1], modTime)
+		}
+	}
+}
+
+// resolveForEdit finds the file a mutation of ref applies to. When a
+// fingerprint is given, a position that no longer points at a headline is
+// not an error yet: locate decides whether the task can be relocated.
+func (s *Service) resolveForEdit(ref string, opts EditOptions) (*Target, error) {
+	t, err := s.Resolve(ref)
+	if err == nil {
+		return t, nil
+	}
+	pos, posErr := parser.ParseFilePosition(ref)
+	if opts.Fingerprint == "" || posErr != nil {
+		return nil, err
+	}
+	if _, statErr := os.Stat(pos.FilePath); statErr != nil {
+		return nil, err
+	}
+	return &Target{FilePath: pos.FilePath, Line: pos.Line}, nil
+}
+
+// locate finds t again in content, which was read under the file lock, and
+// enforces opts. It returns the (possibly relocated) target together with the
+// lines of content.
+func (s *Service) locate(t *Target, ref string, content string, opts EditOptions) (*Target, []string, error) {
+	lines := strings.Split(content, "\n")
+	items := parser.ParseStringWithOptions(content, t.FilePath, s.ParseOptions)
+
+	// A task resolved by ID is looked up by ID again in case it moved
+	// between resolution and locking.
+	var current *item.Item
+	id, customOnly := normalizeID(ref)
+	byID := t.Item != nil && matchesID(t.Item, id, customOnly)
+	for _, it := range items {
+		if (byID && matchesID(it, id, customOnly)) || (!byID && it.LineNumber == t.Line) {
+			current = it
+			break
+		}
+	}
+
+	if opts.Fingerprint == "" {
+		if current == nil {
+			return nil, nil, fmt.Errorf("line %d of %s is not a headline", t.Line, t.FilePath)
+		}
+		return &Target{FilePath: t.FilePath, Line: current.LineNumber, Item: current}, lines, nil
+	}
+
+	want := fingerprintHash(opts.Fingerprint)
+	if current != nil && headlineHash(lines[current.LineNumber-1]) == want {
+		return &Target{FilePath: t.FilePath, Line: current.LineNumber, Item: current}, lines, nil
+	}
+	if !opts.Relocate {
+		return nil, nil, fmt.Errorf("%w: the headline at %s:%d no longer matches fingerprint %s", ErrConflict, t.FilePath, t.Line, opts.Fingerprint)
+	}
+
+	var matches []*item.Item
+	for _, it := range items {
+		if headlineHash(lines[it.LineNumber-1]) == want {
+			matches = append(matches, it)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil, fmt.Errorf("%w: no headline in %s matches fingerprint %s", ErrConflict, t.FilePath, opts.Fingerprint)
+	case 1:
+		return &Target{FilePath: t.FilePath, Line: matches[0].LineNumber, Item: matches[0]}, lines, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %d headlines in %s match fingerprint %s", ErrConflict, len(matches), t.FilePath, opts.Fingerprint)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// SetPriority changes the priority cookie of the task referenced by ref and
+// returns the new priority ("" when removed). priority is a value within the
+// file's priority range, "none" to remove the cookie, or "up" and "down" to
+// move one step from the current (or default) priority. Tags are realigned
+// afterwards.
+func (s *Service) SetPriority(ref, priority string, opts EditOptions) (string, error) {
+	var next string
+	_, err := s.editTask("priority "+ref+" "+priority, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		r := parser.FileOptions(strings.Join(lines, "\n"), s.ParseOptions).Priorities
+		current := it.Priority
+		if current == "" {
+			current = r.Default
+		}
+
+		switch strings.ToLower(priority) {
+		case "none", "":
+			next = ""
+		case "up":
+			if current[0] <= r.Highest[0] {
+				return nil, fmt.Errorf("priority %s is already the highest", current)
+			}
+			next = string(current[0] - 1)
+		case "down":
+			if current[0] >= r.Lowest[0] {
+				return nil, fmt.Errorf("priority %s is already the lowest", current)
+			}
+			next = string(current[0] + 1)
+		default:
+			next = strings.ToUpper(priority)
+			if !r.Contains(next) {
+				return nil, fmt.Errorf("priority %s is outside the range %s-%s", priority, r.Highest, r.Lowest)
+			}
+		}
+
+		lines[idx] = edit.SetPriority(lines[idx], it.Status, next)
+		if len(it.Tags) > 0 {
+			lines[idx] = edit.SetTags(lines[idx], it.Tags, s.TagsColumn)
+		}
+		return lines, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return next, nil
+}
+
+// TagChange describes an edit of a task's tags. When Replace is set the tags
+// are first replaced by Set; Add and Remove apply afterwards.
+type TagChange struct {
+	Replace bool
+	Set     []string
+	Add     []string
+	Remove  []string
+}
+
+// EditTags applies change to the tags of the task referenced by ref and
+// returns the resulting tags. The tags are aligned according to TagsColumn.
+func (s *Service) EditTags(ref string, change TagChange, opts EditOptions) ([]string, error) {
+	for _, tags := range [][]string{change.Set, change.Add, change.Remove} {
+		for _, tag := range tags {
+			if !edit.ValidTag(tag) {
+				return nil, fmt.Errorf("invalid tag %q", tag)
+			}
+		}
+	}
+
+	var result []string
+	_, err := s.editTask("tags "+ref, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		tags := it.Tags
+		if change.Replace {
+			tags = change.Set
+		}
+		result = applyTagChange(tags, change.Add, change.Remove)
+		lines[idx] = edit.SetTags(lines[idx], result, s.TagsColumn)
+		return lines, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyTagChange returns tags with add appended and remove dropped, keeping
+// the order of the existing tags and skipping duplicates.
+func applyTagChange(tags, add, remove []string) []string {
+	removed := map[string]bool{}
+	for _, tag := range remove {
+		removed[tag] = true
+	}
+	seen := map[string]bool{}
+	result := []string{}
+	for _, tag := range append(append([]string{}, tags...), add...) {
+		if tag == "" || removed[tag] || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestService_SetPriority(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task :work:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.TagsColumn = -30
+
+	steps := []struct {
+		priority, want, line string
+	}{
+		{"up", "A", "* TODO [#A] Task        :work:"},
+		{"c", "C", "* TODO [#C] Task        :work:"},
+		{"none", "", "* TODO Task             :work:"},
+		{"down", "C", "* TODO [#C] Task        :work:"},
+	}
+	for _, step := range steps {
+		got, err := svc.SetPriority(file+":1", step.priority, EditOptions{})
+		if err != nil {
+			t.Fatalf("SetPriority(%s) failed: %v", step.priority, err)
+		}
+		if got != step.want {
+			t.Errorf("SetPriority(%s) = %q, want %q", step.priority, got, step.want)
+		}
+		data, _ := os.ReadFile(file)
+		if string(data) != step.line+"\n" {
+			t.Errorf("after SetPriority(%s): got %q, want %q", step.priority, data, step.line+"\n")
+		}
+	}
+
+	if _, err := svc.SetPriority(file+":1", "down", EditOptions{}); err == nil {
+		t.Error("Expected an error below the lowest priority")
+	}
+	if _, err := svc.SetPriority(file+":1", "Z", EditOptions{}); err == nil {
+		t.Error("Expected an error for a priority outside the range")
+	}
+}
+
+func TestService_EditTags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task :work:urgent:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.TagsColumn = 0
+
+	tags, err := svc.EditTags(file+":1", TagChange{Add: []string{"home", "work"}, Remove: []string{"urgent"}}, EditOptions{})
+	if err != nil {
+		t.Fatalf("EditTags failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"work", "home"}) {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* TODO Task :work:home:\n" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+
+	if _, err := svc.EditTags(file+":1", TagChange{Replace: true}, EditOptions{}); err != nil {
+		t.Fatalf("EditTags failed: %v", err)
+	}
+	data, _ = os.ReadFile(file)
+	if string(data) != "* TODO Task\n" {
+		t.Errorf("Expected all tags to be cleared, got %q", data)
+	}
+
+	if _, err := svc.EditTags(file+":1", TagChange{Add: []string{"not a tag"}}, EditOptions{}); err == nil {
+		t.Error("Expected an error for an invalid tag")
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// idIndex caches which file defines each :ID: or :CUSTOM_ID: so that a
+// lookup does not have to parse every configured file. It is only a hint:
+// callers verify each hit against the file and rescan on a miss.
+type idIndex struct {
+	path    string
+	entries map[string]string
+}
+
+// DefaultIDIndexPath returns the location of the ID index under the user's
+// cache directory.
+func DefaultIDIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "org-agenda-cli", "id-index.json")
+}
+
+// loadIDIndex reads the index at path. A missing or corrupt file yields an
+// empty index; an empty path yields an index that is never persisted.
+func loadIDIndex(path string) *idIndex {
+	idx := &idIndex{path: path, entries: map[string]string{}}
+	if path == "" {
+		return idx
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return idx
+	}
+	if err := json.Unmarshal(data, &idx.entries); err != nil || idx.entries == nil {
+		idx.entries = map[string]string{}
+	}
+	return idx
+}
+
+func (idx *idIndex) lookup(id string) (string, bool) {
+	file, ok := idx.entries[id]
+	return file, ok
+}
+
+func (idx *idIndex) save() error {
+	if idx.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(idx.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(idx.path, data, 0644)
+}
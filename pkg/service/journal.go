@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/garaemon/org-agenda-cli/pkg/journal"
+	"github.com/garaemon/org-agenda-cli/pkg/orgfile"
+)
+
+// update is orgfile.Update recording the change in the undo journal as op.
+func (s *Service) update(op, path string, fn func(content []byte) ([]byte, error)) error {
+	var before, after []byte
+	err := orgfile.Update(path, func(content []byte) ([]byte, error) {
+		updated, err := fn(content)
+		before, after = content, updated
+		return updated, err
+	})
+	if err != nil {
+		return err
+	}
+	if before == nil || string(before) != string(after) {
+		s.record(op, journal.NewChange(path, before, after))
+	}
+	return nil
+}
+
+// record adds the changes made by op to the undo journal. A journal that
+// cannot be written does not fail the operation, which already happened.
+func (s *Service) record(op string, changes ...journal.Change) {
+	if s.JournalPath == "" {
+		return
+	}
+	entry := journal.Entry{Time: now(), Op: op, Changes: changes}
+	if err := journal.Open(s.JournalPath).Append(entry); err != nil {
+		orgfile.Warn(fmt.Sprintf("failed to record the change in the undo journal: %v", err))
+	}
+}
+
+// History returns the journaled operations, oldest first.
+func (s *Service) History() ([]journal.Entry, error) {
+	if s.JournalPath == "" {
+		return nil, fmt.Errorf("the undo journal is disabled")
+	}
+	return journal.Open(s.JournalPath).Entries()
+}
+
+// Undo reverts the last steps journaled operations, newest first, and
+// returns the ones it reverted. An operation is only reverted when none of
+// the files it changed has been modified since; undoing stops at the first
+// one that cannot be reverted.
+func (s *Service) Undo(steps int) ([]journal.Entry, error) {
+	if s.JournalPath == "" {
+		return nil, fmt.Errorf("the undo journal is disabled")
+	}
+	return journal.Open(s.JournalPath).Undo(steps, s.revert)
+}
+
+// revert restores the files changed by e, all or none of them.
+func (s *Service) revert(e journal.Entry) error {
+	unlock, err := orgfile.LockAll(e.Files()...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	reverted := make([][]byte, len(e.Changes))
+	for i, c := range e.Changes {
+		current, err := os.ReadFile(c.File)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		if reverted[i], err = c.Revert(current); err != nil {
+			return fmt.Errorf("cannot undo %q: %w", e.Op, err)
+		}
+	}
+	for i, c := range e.Changes {
+		if c.Created {
+			if err := os.Remove(c.File); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", c.File, err)
+			}
+			continue
+		}
+		if err := orgfile.WriteFile(c.File, reverted[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
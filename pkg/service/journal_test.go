@@ -0,0 +1,108 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestService_Undo(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	original := "* TODO Task\n"
+	if err := os.WriteFile(file, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.JournalPath = filepath.Join(dir, "journal.jsonl")
+
+	if err := svc.MarkDone(file+":1", EditOptions{}); err != nil {
+		t.Fatalf("MarkDone failed: %v", err)
+	}
+	if err := svc.AddTodo("Second", AddOptions{}); err != nil {
+		t.Fatalf("AddTodo failed: %v", err)
+	}
+	entries, err := svc.History()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 journal entries, got %d (%v)", len(entries), err)
+	}
+	if entries[0].Op != "done "+file+":1" || entries[1].Op != `add "Second"` {
+		t.Errorf("Unexpected operations: %q, %q", entries[0].Op, entries[1].Op)
+	}
+
+	undone, err := svc.Undo(2)
+	if err != nil || len(undone) != 2 {
+		t.Fatalf("Undo failed: %v (%d undone)", err, len(undone))
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != original {
+		t.Errorf("Expected the original content back, got %q", data)
+	}
+	if entries, _ := svc.History(); len(entries) != 0 {
+		t.Errorf("Expected an empty journal, got %d entries", len(entries))
+	}
+}
+
+func TestService_UndoDiverged(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.JournalPath = filepath.Join(dir, "journal.jsonl")
+
+	if err := svc.MarkDone(file+":1", EditOptions{}); err != nil {
+		t.Fatalf("MarkDone failed: %v", err)
+	}
+	edited := "* DONE Task edited in Emacs\n"
+	if err := os.WriteFile(file, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	undone, err := svc.Undo(1)
+	if err == nil || len(undone) != 0 {
+		t.Fatalf("Expected undo to refuse a diverged file, got %v (%d undone)", err, len(undone))
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != edited {
+		t.Errorf("Diverged file was modified: %q", data)
+	}
+	if entries, _ := svc.History(); len(entries) != 1 {
+		t.Errorf("Expected the entry to stay in the journal, got %d entries", len(entries))
+	}
+}
+
+func TestService_UndoArchive(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	original := "* DONE Task\n* TODO Other\n"
+	if err := os.WriteFile(file, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.JournalPath = filepath.Join(dir, "journal.jsonl")
+
+	if _, err := svc.Archive(file+":1", false, EditOptions{}); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	entries, _ := svc.History()
+	if len(entries) != 1 || len(entries[0].Changes) != 2 || !strings.HasPrefix(entries[0].Op, "archive ") {
+		t.Fatalf("Expected one archive entry with two changes, got %+v", entries)
+	}
+
+	if _, err := svc.Undo(1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != original {
+		t.Errorf("Expected the original content back, got %q", data)
+	}
+	if _, err := os.Stat(file + "_archive"); !os.IsNotExist(err) {
+		t.Error("Expected the created archive file to be removed")
+	}
+}
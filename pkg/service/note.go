@@ -0,0 +1,41 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// Places AddNote puts notes, selected by Service.NoteLocation.
+const (
+	NoteInLogbook = "logbook"
+	NoteInBody    = "body"
+)
+
+// AddNote adds note to the task referenced by ref the way Org's org-add-note
+// does, as a "Note taken on" entry. With NoteInBody the entry is appended to
+// the end of the task's body; otherwise it goes first into its log drawer
+// (log_into_drawer, or LOGBOOK when that is not set).
+func (s *Service) AddNote(ref, note string, opts EditOptions) error {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return fmt.Errorf("note is empty")
+	}
+
+	_, err := s.editTask("note "+ref, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		heading := "Note taken on " + parser.FormatTimestamp(now(), false, true)
+		entry := logEntry(heading, note)
+		if s.NoteLocation == NoteInBody {
+			return edit.AppendToBody(lines, idx, entry...), nil
+		}
+		drawer := parser.FileOptions(strings.Join(lines, "\n"), s.ParseOptions).LogDrawer
+		if drawer == "" {
+			drawer = "LOGBOOK"
+		}
+		return edit.AddLogEntry(lines, idx, drawer, entry), nil
+	})
+	return err
+}
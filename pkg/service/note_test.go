@@ -0,0 +1,52 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestService_AddNote(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	setNow(t, time.Date(2026, 1, 5, 10, 12, 0, 0, time.UTC))
+
+	content := "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: task-1\n:END:\nBody\n\n* Next\n"
+	tests := []struct {
+		name     string
+		location string
+		drawer   string
+		want     string
+	}{
+		{"logbook", NoteInLogbook, "", "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: task-1\n:END:\n:LOGBOOK:\n- Note taken on [2026-01-05 Mon 10:12] \\\\\n  First line\n  second line\n:END:\nBody\n\n* Next\n"},
+		{"log drawer", NoteInLogbook, "NOTES", "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: task-1\n:END:\n:NOTES:\n- Note taken on [2026-01-05 Mon 10:12] \\\\\n  First line\n  second line\n:END:\nBody\n\n* Next\n"},
+		{"body", NoteInBody, "LOGBOOK", "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: task-1\n:END:\nBody\n- Note taken on [2026-01-05 Mon 10:12] \\\\\n  First line\n  second line\n\n* Next\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "tasks.org")
+			if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			svc := NewService([]string{file}, file)
+			svc.NoteLocation = tt.location
+			svc.ParseOptions.LogDrawer = tt.drawer
+
+			if err := svc.AddNote("task-1", "First line\nsecond line\n", EditOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			data, _ := os.ReadFile(file)
+			if string(data) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", data, tt.want)
+			}
+		})
+	}
+
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewService([]string{file}, file).AddNote(file+":1", "  \n", EditOptions{}); err == nil {
+		t.Error("expected an empty note to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/dateinput"
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// repeaterRegex matches the repeater and warning period cookies of a
+// timestamp, e.g. " +1w" or " -2d".
+var repeaterRegex = regexp.MustCompile(`\s((?:\.\+|\+\+|\+)\d+[hdwmy](?:/\d+[hdwmy])?|--?\d+[hdwmy])`)
+
+// Reschedule sets the SCHEDULED date of the task referenced by ref to the
+// date read from input (see dateinput.Parse), or removes it when input is
+// empty, and returns the timestamp written. Changing or removing an existing
+// date is logged when log_reschedule asks for it or a note is given.
+func (s *Service) Reschedule(ref, input, note string, opts EditOptions) (string, error) {
+	return s.setPlanning(ref, "SCHEDULED", input, note, opts)
+}
+
+// SetDeadline is Reschedule for the DEADLINE date, logged according to
+// log_redeadline.
+func (s *Service) SetDeadline(ref, input, note string, opts EditOptions) (string, error) {
+	return s.setPlanning(ref, "DEADLINE", input, note, opts)
+}
+
+func (s *Service) setPlanning(ref, key, input, note string, opts EditOptions) (string, error) {
+	ts := ""
+	if strings.TrimSpace(input) != "" {
+		d, err := dateinput.Parse(input, now())
+		if err != nil {
+			return "", err
+		}
+		ts = d.Timestamp()
+	}
+
+	_, err := s.editTask(strings.ToLower(key)+" "+ref, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		lines, ts = s.changePlanning(lines, idx, key, ts, note)
+		return lines, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return ts, nil
+}
+
+// changePlanning sets key of the headline at idx to the timestamp ts, or
+// removes it when ts is empty, keeping the repeaters of the previous date, and
+// logs the change as configured. It returns the new lines and the timestamp
+// written.
+func (s *Service) changePlanning(lines []string, idx int, key, ts, note string) ([]string, string) {
+	old := edit.PlanningTimestamp(lines, idx, key)
+	if old != "" && ts != "" {
+		ts = keepRepeaters(old, ts)
+	}
+	lines = edit.SetPlanning(lines, idx, key, ts)
+
+	fileOpts := parser.FileOptions(strings.Join(lines, "\n"), s.ParseOptions)
+	logging := fileOpts.LogReschedule
+	if key == "DEADLINE" {
+		logging = fileOpts.LogRedeadline
+	}
+	if old == "" || (logging == item.LogNone && note == "") {
+		return lines, ts
+	}
+	heading := planningLogHeading(key, ts == "", inactive(old), parser.FormatTimestamp(now(), false, true))
+	return edit.AddLogEntry(lines, idx, fileOpts.LogDrawer, logEntry(heading, note)), ts
+}
+
+// planningLogHeading mirrors the reschedule, delschedule, redeadline and
+// deldeadline entries of org-log-note-headings.
+func planningLogHeading(key string, removed bool, old, ts string) string {
+	format := "Rescheduled from %s on %s"
+	switch {
+	case key == "SCHEDULED" && removed:
+		format = "Not scheduled, was %s on %s"
+	case key == "DEADLINE" && removed:
+		format = "Removed deadline, was %s on %s"
+	case key == "DEADLINE":
+		format = "New deadline from %s on %s"
+	}
+	return fmt.Sprintf(format, strconv.Quote(old), ts)
+}
+
+// keepRepeaters carries the repeater and warning period of the old timestamp
+// over to the new one, as Org does when rescheduling.
+func keepRepeaters(old, ts string) string {
+	if repeaterRegex.MatchString(ts) {
+		return ts
+	}
+	var cookies string
+	for _, m := range repeaterRegex.FindAllStringSubmatch(old, -1) {
+		cookies += " " + m[1]
+	}
+	if cookies == "" {
+		return ts
+	}
+	return ts[:len(ts)-1] + cookies + ts[len(ts)-1:]
+}
+
+// inactive turns an active timestamp into an inactive one.
+func inactive(ts string) string {
+	if strings.HasPrefix(ts, "<") && strings.HasSuffix(ts, ">") {
+		return "[" + ts[1:len(ts)-1] + "]"
+	}
+	return ts
+}
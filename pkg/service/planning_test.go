@@ -0,0 +1,80 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+)
+
+func TestService_Reschedule(t *testing.T) {
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\nSCHEDULED: <2026-01-02 Fri +1w>\nBody\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService([]string{file}, file)
+	svc.ParseOptions.LogReschedule = item.LogTime
+	svc.ParseOptions.LogDrawer = "LOGBOOK"
+
+	ts, err := svc.Reschedule(file+":1", "next mon", "", EditOptions{})
+	if err != nil {
+		t.Fatalf("Reschedule failed: %v", err)
+	}
+	if ts != "<2026-01-12 Mon +1w>" {
+		t.Errorf("Expected the repeater to be kept, got %s", ts)
+	}
+
+	data, _ := os.ReadFile(file)
+	expected := `* TODO Task
+SCHEDULED: <2026-01-12 Mon +1w>
+:LOGBOOK:
+- Rescheduled from "[2026-01-02 Fri +1w]" on [2026-01-05 Mon 10:00]
+:END:
+Body
+`
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	items, _ := svc.ListTodos(ListOptions{})
+	if len(items) != 1 || items[0].Scheduled == nil || items[0].Scheduled.Format("2006-01-02") != "2026-01-12" {
+		t.Errorf("Unexpected scheduled date: %+v", items)
+	}
+}
+
+func TestService_SetDeadline(t *testing.T) {
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	if _, err := svc.SetDeadline(file+":1", "fri 17:00", "", EditOptions{}); err != nil {
+		t.Fatalf("SetDeadline failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "* TODO Task\nSCHEDULED: <2026-01-05 Mon> DEADLINE: <2026-01-09 Fri 17:00>\n" {
+		t.Errorf("Unexpected content after setting the deadline: %q", data)
+	}
+
+	// Removing is not logged by default, but a note forces an entry.
+	if _, err := svc.SetDeadline(file+":1", "", "Dropped by the client", EditOptions{}); err != nil {
+		t.Fatalf("SetDeadline removal failed: %v", err)
+	}
+	data, _ = os.ReadFile(file)
+	expected := "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n" +
+		`- Removed deadline, was "[2026-01-09 Fri 17:00]" on [2026-01-05 Mon 10:00] \\` + "\n" +
+		"  Dropped by the client\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	if _, err := svc.SetDeadline(file+":1", "someday", "", EditOptions{}); err == nil {
+		t.Error("Expected an error for an unreadable date")
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+)
+
+// propertyKeyRegex matches the names Org accepts in a property drawer.
+var propertyKeyRegex = regexp.MustCompile(`^[^:\s]+$`)
+
+// GetProperty returns the value of the property key of the task referenced
+// by ref. Keys are case-insensitive.
+func (s *Service) GetProperty(ref, key string) (string, error) {
+	t, err := s.Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(t.FilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	value, ok := edit.GetProperty(strings.Split(string(content), "\n"), t.Line-1, key)
+	if !ok {
+		return "", fmt.Errorf("property %s is not set", key)
+	}
+	return value, nil
+}
+
+// SetProperty sets the property key of the task referenced by ref to value.
+// The property drawer is created after the planning line when missing.
+func (s *Service) SetProperty(ref, key, value string, opts EditOptions) error {
+	if err := validatePropertyKey(key); err != nil {
+		return err
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("property values cannot span several lines")
+	}
+	_, err := s.editTask("prop "+ref+" set "+key, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		return edit.SetProperty(lines, idx, key, strings.TrimSpace(value)), nil
+	})
+	return err
+}
+
+// DeleteProperty removes the property key from the task referenced by ref,
+// together with the property drawer when nothing else is left in it.
+func (s *Service) DeleteProperty(ref, key string, opts EditOptions) error {
+	if err := validatePropertyKey(key); err != nil {
+		return err
+	}
+	_, err := s.editTask("prop "+ref+" delete "+key, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		lines, ok := edit.DeleteProperty(lines, idx, key)
+		if !ok {
+			return nil, fmt.Errorf("property %s is not set", key)
+		}
+		return lines, nil
+	})
+	return err
+}
+
+func validatePropertyKey(key string) error {
+	if !propertyKeyRegex.MatchString(key) || strings.EqualFold(key, "END") || strings.EqualFold(key, "PROPERTIES") {
+		return fmt.Errorf("invalid property name %q", key)
+	}
+	return nil
+}
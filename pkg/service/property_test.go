@@ -0,0 +1,60 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestService_Properties(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "* TODO Task\nDEADLINE: <2026-01-09 Fri>\n:LOGBOOK:\n- note\n:END:\nBody\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	ref := file + ":1"
+
+	if err := svc.SetProperty(ref, "OWNER", "alice", EditOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetProperty(ref, "TICKET", "OPS-12", EditOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetProperty(ref, "owner", "bob", EditOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "* TODO Task\nDEADLINE: <2026-01-09 Fri>\n:PROPERTIES:\n:OWNER: bob\n:TICKET: OPS-12\n:END:\n:LOGBOOK:\n- note\n:END:\nBody\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	if value, err := svc.GetProperty(ref, "Owner"); err != nil || value != "bob" {
+		t.Errorf("GetProperty(Owner) = %q, %v", value, err)
+	}
+	if _, err := svc.GetProperty(ref, "EFFORT"); err == nil {
+		t.Error("expected an error for a missing property")
+	}
+
+	for _, key := range []string{"OWNER", "TICKET"} {
+		if err := svc.DeleteProperty(ref, key, EditOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, _ := os.ReadFile(file); string(data) != content {
+		t.Errorf("expected the original content once both properties are deleted, got:\n%s", data)
+	}
+	if err := svc.DeleteProperty(ref, "OWNER", EditOptions{}); err == nil {
+		t.Error("expected an error when deleting a missing property")
+	}
+
+	for _, key := range []string{"", "TWO WORDS", "A:B", "END"} {
+		if err := svc.SetProperty(ref, key, "x", EditOptions{}); err == nil {
+			t.Errorf("expected property name %q to be rejected", key)
+		}
+	}
+	if err := svc.SetProperty(ref, "NOTE", "a\nb", EditOptions{}); err == nil {
+		t.Error("expected a multi-line value to be rejected")
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/journal"
+	"github.com/garaemon/org-agenda-cli/pkg/orgfile"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// RefileScope limits the headlines offered as refile targets in File to
+// those at most MaxLevel deep (1 when unset), like an entry of
+// org-refile-targets. An empty File applies to every configured Org file not
+// listed explicitly.
+type RefileScope struct {
+	File     string
+	MaxLevel int
+}
+
+// RefileTarget is a place a subtree can be refiled to: the headline at the
+// outline path OLP in File, or the top level of File when OLP is empty.
+type RefileTarget struct {
+	File string   `json:"file"`
+	OLP  []string `json:"olp,omitempty"`
+	Line int      `json:"line,omitempty"`
+}
+
+// String renders t as "file:Projects/Website".
+func (t RefileTarget) String() string {
+	return t.File + ":" + strings.Join(t.OLP, "/")
+}
+
+// ListRefileTargets enumerates the headlines allowed by RefileScopes, or the
+// top-level headlines of every Org file when no scope is configured. Each
+// file is offered as a target of its own before its headlines.
+func (s *Service) ListRefileTargets() ([]RefileTarget, error) {
+	var targets []RefileTarget
+	for _, scope := range s.refileScopes() {
+		content, err := os.ReadFile(scope.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", scope.File, err)
+		}
+		targets = append(targets, RefileTarget{File: scope.File})
+		maxLevel := scope.MaxLevel
+		if maxLevel < 1 {
+			maxLevel = 1
+		}
+
+		var path []string
+		for _, it := range parser.ParseStringWithOptions(string(content), scope.File, s.ParseOptions) {
+			if it.Level <= len(path) {
+				path = path[:it.Level-1]
+			}
+			for len(path) < it.Level-1 {
+				// Skipped levels have no title of their own.
+				path = append(path, "")
+			}
+			path = append(path, it.Title)
+			if it.Level > maxLevel {
+				continue
+			}
+			targets = append(targets, RefileTarget{
+				File: scope.File,
+				OLP:  append([]string(nil), path...),
+				Line: it.LineNumber,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// refileScopes expands RefileScopes into one scope per file.
+func (s *Service) refileScopes() []RefileScope {
+	if len(s.RefileScopes) == 0 {
+		scopes := make([]RefileScope, len(s.OrgFiles))
+		for i, file := range s.OrgFiles {
+			scopes[i] = RefileScope{File: file, MaxLevel: 1}
+		}
+		return scopes
+	}
+
+	var scopes []RefileScope
+	listed := map[string]bool{}
+	for _, scope := range s.RefileScopes {
+		if scope.File != "" {
+			listed[filepath.Clean(scope.File)] = true
+		}
+	}
+	for _, scope := range s.RefileScopes {
+		if scope.File != "" {
+			scopes = append(scopes, scope)
+			continue
+		}
+		for _, file := range s.OrgFiles {
+			if !listed[filepath.Clean(file)] {
+				scopes = append(scopes, RefileScope{File: file, MaxLevel: scope.MaxLevel})
+			}
+		}
+	}
+	return scopes
+}
+
+// Refile moves the subtree of the task referenced by ref, with its body and
+// children, below the headline at to.OLP in to.File (the task's own file when
+// empty). Headline levels are shifted to fit the new parent, and an empty OLP
+// moves the subtree to the top level at the end of the file.
+func (s *Service) Refile(ref string, to RefileTarget, opts EditOptions) (*Target, error) {
+	dest := func(lines []string, idx int, it *item.Item) (string, error) {
+		if to.File == "" {
+			return it.FilePath, nil
+		}
+		if _, err := os.Stat(to.File); err != nil {
+			return "", fmt.Errorf("refile target %s does not exist", to.File)
+		}
+		return to.File, nil
+	}
+	return s.moveSubtree("refile "+ref, ref, opts, dest, func(m *subtreeMove) ([]string, int, error) {
+		if m.InPlace {
+			if parent, _, err := s.findOLP(m.Lines, m.File, to.OLP); err == nil && parent >= m.Start && parent < m.End {
+				return nil, 0, fmt.Errorf("cannot refile %q below itself", m.Item.Title)
+			}
+		}
+		parent, level, err := s.findOLP(m.Dest, m.File, to.OLP)
+		if err != nil {
+			return nil, 0, err
+		}
+		dest, at := insertSubtree(m.Dest, parent, level, m.Subtree)
+		return dest, at, nil
+	})
+}
+
+// subtreeMove describes a subtree being moved by moveSubtree. The subtree
+// spans lines [Start, End) of Lines, the source file. Dest holds the lines of
+// File, the destination, which lack the subtree already when InPlace is set.
+type subtreeMove struct {
+	Lines      []string
+	Start, End int
+	Item       *item.Item
+	Subtree    []string
+	File       string
+	Dest       []string
+	InPlace    bool
+}
+
+// moveSubtree cuts the subtree of the task referenced by ref and lets place
+// insert it into the file named by dest, returning the new lines of that file
+// and the index the subtree's headline ended up at. A destination that does
+// not exist yet is created. Both files are locked for the whole move and the
+// destination is written before the source, so an interrupted move leaves a
+// copy behind rather than losing the subtree. The move is journaled as op.
+func (s *Service) moveSubtree(op, ref string, opts EditOptions, dest func(lines []string, idx int, it *item.Item) (string, error), place func(m *subtreeMove) ([]string, int, error)) (*Target, error) {
+	t, err := s.resolveForEdit(ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(t.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	current, lines, err := s.locate(t, ref, string(content), opts)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := dest(lines, current.Line-1, current.Item)
+	if err != nil {
+		return nil, err
+	}
+	inPlace := sameFile(t.FilePath, dst)
+
+	paths := []string{t.FilePath}
+	if !inPlace {
+		paths = append(paths, dst)
+	}
+	unlock, err := orgfile.LockAll(paths...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// The destination was derived from the file before it was locked.
+	content, err = os.ReadFile(t.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	current, lines, err = s.locate(t, ref, string(content), opts)
+	if err != nil {
+		return nil, err
+	}
+	if again, err := dest(lines, current.Line-1, current.Item); err != nil || !sameFile(again, dst) {
+		return nil, fmt.Errorf("%w: the destination of %s changed while it was being moved", ErrConflict, ref)
+	}
+
+	m := &subtreeMove{Lines: trimTrailingEmpty(lines), Start: current.Line - 1, Item: current.Item, File: dst, InPlace: inPlace}
+	m.End = edit.SubtreeEnd(m.Lines, m.Start)
+	m.Subtree = append([]string(nil), m.Lines[m.Start:m.End]...)
+	rest := append(append([]string(nil), m.Lines[:m.Start]...), m.Lines[m.End:]...)
+	m.Dest = rest
+	dstContent := content
+	if !inPlace {
+		dstContent, err = os.ReadFile(dst)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		m.Dest = nil
+		if len(dstContent) > 0 {
+			m.Dest = trimTrailingEmpty(strings.Split(string(dstContent), "\n"))
+		}
+	}
+
+	dstLines, at, err := place(m)
+	if err != nil {
+		return nil, err
+	}
+	dstOutput := []byte(edit.JoinLines(dstLines))
+	if err := orgfile.WriteFile(dst, dstOutput); err != nil {
+		return nil, err
+	}
+	changes := []journal.Change{journal.NewChange(dst, dstContent, dstOutput)}
+	if !inPlace {
+		srcOutput := []byte(edit.JoinLines(rest))
+		if err := orgfile.WriteFile(t.FilePath, srcOutput); err != nil {
+			return nil, err
+		}
+		changes = append(changes, journal.NewChange(t.FilePath, content, srcOutput))
+		s.reindexMoved(m.Subtree, dst)
+	}
+	s.record(op, changes...)
+
+	return &Target{FilePath: dst, Line: at + 1, Item: current.Item}, nil
+}
+
+// insertSubtree inserts subtree as the last child of the headline at parent,
+// whose level is level, or at the end of lines when parent is -1. It returns
+// the new lines and the index of the subtree's headline.
+func insertSubtree(lines []string, parent, level int, subtree []string) ([]string, int) {
+	at := len(lines)
+	if parent != -1 {
+		at = edit.SubtreeEnd(lines, parent)
+	}
+	moved := strings.Split(capture.AdjustEntryLevel(strings.Join(subtree, "\n"), level+1), "\n")
+	result := make([]string, 0, len(lines)+len(moved))
+	result = append(result, lines[:at]...)
+	result = append(result, moved...)
+	result = append(result, lines[at:]...)
+	return result, at
+}
+
+// findOLP returns the index and level of the headline at olp in lines, or -1
+// and 0 for an empty olp. Like capture, each title is searched for anywhere
+// below the previous one.
+func (s *Service) findOLP(lines []string, file string, olp []string) (int, int, error) {
+	items := parser.ParseStringWithOptions(strings.Join(lines, "\n"), file, s.ParseOptions)
+	idx, level := -1, 0
+	for _, title := range olp {
+		end := len(lines)
+		if idx != -1 {
+			end = edit.SubtreeEnd(lines, idx)
+		}
+		var found *item.Item
+		for _, it := range items {
+			if it.LineNumber-1 > idx && it.LineNumber-1 < end && it.Level > level && it.Title == title {
+				found = it
+				break
+			}
+		}
+		if found == nil {
+			return -1, 0, fmt.Errorf("target headline '%s' not found in %s", strings.Join(olp, " > "), file)
+		}
+		idx, level = found.LineNumber-1, found.Level
+	}
+	return idx, level, nil
+}
+
+// reindexMoved points the ID index entries of the headlines in subtree at
+// file.
+func (s *Service) reindexMoved(subtree []string, file string) {
+	idx := loadIDIndex(s.IDIndexPath)
+	changed := false
+	for _, it := range parser.ParseStringWithOptions(strings.Join(subtree, "\n"), file, s.ParseOptions) {
+		for _, id := range []string{it.ID, it.CustomID} {
+			if id != "" {
+				idx.entries[id] = file
+				changed = true
+			}
+		}
+	}
+	if changed {
+		_ = idx.save()
+	}
+}
+
+// trimTrailingEmpty drops the empty element a trailing newline leaves at the
+// end of lines.
+func trimTrailingEmpty(lines []string) []string {
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestService_Refile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	inbox := filepath.Join(dir, "inbox.org")
+	projects := filepath.Join(dir, "projects.org")
+	if err := os.WriteFile(inbox, []byte("* TODO Keep\n* TODO Redesign\n:PROPERTIES:\n:ID: redesign\n:END:\nBody\n** TODO Mockups\n* TODO Last\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projects, []byte("* Projects\n** Website\n*** TODO Existing\n** Other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{inbox, projects}, inbox)
+	svc.IDIndexPath = filepath.Join(dir, "index.json")
+
+	moved, err := svc.Refile("redesign", RefileTarget{File: projects, OLP: []string{"Projects", "Website"}}, EditOptions{})
+	if err != nil {
+		t.Fatalf("Refile failed: %v", err)
+	}
+	if moved.FilePath != projects || moved.Line != 4 {
+		t.Errorf("Unexpected target: %s:%d", moved.FilePath, moved.Line)
+	}
+
+	data, _ := os.ReadFile(inbox)
+	if string(data) != "* TODO Keep\n* TODO Last\n" {
+		t.Errorf("Unexpected source: %q", data)
+	}
+	data, _ = os.ReadFile(projects)
+	expected := "* Projects\n** Website\n*** TODO Existing\n*** TODO Redesign\n:PROPERTIES:\n:ID: redesign\n:END:\nBody\n**** TODO Mockups\n** Other\n"
+	if string(data) != expected {
+		t.Errorf("Unexpected target file:\ngot  %q\nwant %q", data, expected)
+	}
+	if file, ok := loadIDIndex(svc.IDIndexPath).lookup("redesign"); !ok || file != projects {
+		t.Errorf("ID index not updated: %q, %v", file, ok)
+	}
+
+	// Refiling to the top level of the same file.
+	if _, err := svc.Refile(projects+":4", RefileTarget{}, EditOptions{}); err != nil {
+		t.Fatalf("Refile to top level failed: %v", err)
+	}
+	data, _ = os.ReadFile(projects)
+	expected = "* Projects\n** Website\n*** TODO Existing\n** Other\n* TODO Redesign\n:PROPERTIES:\n:ID: redesign\n:END:\nBody\n** TODO Mockups\n"
+	if string(data) != expected {
+		t.Errorf("Unexpected file after refiling to the top level:\ngot  %q\nwant %q", data, expected)
+	}
+}
+
+func TestService_RefileErrors(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "* Projects\n** Website\n* Other\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	if _, err := svc.Refile(file+":1", RefileTarget{OLP: []string{"Projects", "Website"}}, EditOptions{}); err == nil {
+		t.Error("Expected an error when refiling below itself")
+	}
+	if _, err := svc.Refile(file+":3", RefileTarget{OLP: []string{"Missing"}}, EditOptions{}); err == nil {
+		t.Error("Expected an error for a missing target headline")
+	}
+	if _, err := svc.Refile(file+":3", RefileTarget{File: file + ".missing"}, EditOptions{}); err == nil {
+		t.Error("Expected an error for a missing target file")
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != content {
+		t.Errorf("File changed by a failed refile: %q", data)
+	}
+}
+
+func TestService_ListRefileTargets(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.org")
+	b := filepath.Join(dir, "b.org")
+	if err := os.WriteFile(a, []byte("* One\n** Two\n*** Three\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("* Alpha\n** Beta\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{a, b}, a)
+
+	targets, err := svc.ListRefileTargets()
+	if err != nil {
+		t.Fatalf("ListRefileTargets failed: %v", err)
+	}
+	var got []string
+	for _, target := range targets {
+		got = append(got, target.String())
+	}
+	expected := []string{a + ":", a + ":One", b + ":", b + ":Alpha"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Default targets: got %v, want %v", got, expected)
+	}
+
+	svc.RefileScopes = []RefileScope{{File: a, MaxLevel: 3}, {MaxLevel: 2}}
+	targets, err = svc.ListRefileTargets()
+	if err != nil {
+		t.Fatalf("ListRefileTargets failed: %v", err)
+	}
+	got = nil
+	for _, target := range targets {
+		got = append(got, target.String())
+	}
+	expected = []string{a + ":", a + ":One", a + ":One/Two", a + ":One/Two/Three", b + ":", b + ":Alpha", b + ":Alpha/Beta"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Scoped targets: got %v, want %v", got, expected)
+	}
+}
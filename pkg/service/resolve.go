@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+	"github.com/google/uuid"
+)
+
+// Target is a headline located by Resolve. Line is 1-based.
+type Target struct {
+	FilePath string
+	Line     int
+	Item     *item.Item
+}
+
+// Resolve locates the task referenced by ref. A reference is either
+// "file:line" or the value of an :ID: or :CUSTOM_ID: property, optionally
+// written as an Org link target ("id:<uuid>" or "#custom-id").
+func (s *Service) Resolve(ref string) (*Target, error) {
+	pos, posErr := parser.ParseFilePosition(ref)
+	if posErr == nil {
+		if _, err := os.Stat(pos.FilePath); err == nil {
+			return s.targetAt(pos.FilePath, pos.Line)
+		}
+	}
+
+	t, err := s.findByID(ref)
+	if err != nil && posErr == nil {
+		// It looked like a position, so report why that did not work.
+		return nil, fmt.Errorf("failed to read file: %s does not exist", pos.FilePath)
+	}
+	return t, err
+}
+
+// targetAt returns the headline at the 1-based line of file.
+func (s *Service) targetAt(file string, line int) (*Target, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return nil, fmt.Errorf("line number %d out of range", line)
+	}
+	items := parser.ParseStringWithOptions(string(content), file, s.ParseOptions)
+	if info, err := os.Stat(file); err == nil {
+		setFingerprints(items, string(content), info.ModTime())
+	}
+	for _, it := range items {
+		if it.LineNumber == line {
+			return &Target{FilePath: file, Line: line, Item: it}, nil
+		}
+	}
+	return nil, fmt.Errorf("line %d of %s is not a headline", line, file)
+}
+
+// findByID looks ref up in the ID index and falls back to scanning every
+// configured file, refreshing the index on the way.
+func (s *Service) findByID(ref string) (*Target, error) {
+	id, customOnly := normalizeID(ref)
+	if id == "" {
+		return nil, fmt.Errorf("invalid task reference %q", ref)
+	}
+
+	idx := loadIDIndex(s.IDIndexPath)
+	if file, ok := idx.lookup(id); ok {
+		if t := s.findIDInFile(file, id, customOnly); t != nil {
+			return t, nil
+		}
+	}
+
+	var found *Target
+	idx.entries = map[string]string{}
+	items, _ := s.LoadItems()
+	for _, it := range items {
+		if it.ID != "" {
+			idx.entries[it.ID] = it.FilePath
+		}
+		if it.CustomID != "" {
+			idx.entries[it.CustomID] = it.FilePath
+		}
+		if found == nil && matchesID(it, id, customOnly) {
+			found = &Target{FilePath: it.FilePath, Line: it.LineNumber, Item: it}
+		}
+	}
+	// The index is only a cache, so failing to persist it is not fatal.
+	_ = idx.save()
+
+	if found == nil {
+		return nil, fmt.Errorf("no task with ID %q found", id)
+	}
+	return found, nil
+}
+
+func (s *Service) findIDInFile(file, id string, customOnly bool) *Target {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	for _, it := range parser.ParseStringWithOptions(string(content), file, s.ParseOptions) {
+		if matchesID(it, id, customOnly) {
+			return &Target{FilePath: file, Line: it.LineNumber, Item: it}
+		}
+	}
+	return nil
+}
+
+// normalizeID strips the Org link prefixes from ref. The second result is
+// true for "#custom-id" references, which only match CUSTOM_ID.
+func normalizeID(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "#") {
+		return strings.TrimPrefix(ref, "#"), true
+	}
+	return strings.TrimPrefix(ref, "id:"), false
+}
+
+func matchesID(it *item.Item, id string, customOnly bool) bool {
+	if customOnly {
+		return it.CustomID == id
+	}
+	return it.ID == id || it.CustomID == id
+}
+
+// editTask resolves ref and rewrites its file with the lines returned by fn,
+// which receives the 0-based index of the headline. The file is locked while
+// the task is located again and modified, and the change is journaled as op.
+// When AutoID is enabled a task without an :ID: gets one as part of the same
+// write.
+func (s *Service) editTask(op, ref string, opts EditOptions, fn func(lines []string, idx int, it *item.Item) ([]string, error)) (*Target, error) {
+	t, err := s.resolveForEdit(ref, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *Target
+	assignedID := ""
+	err = s.update(op, t.FilePath, func(content []byte) ([]byte, error) {
+		current, lines, err := s.locate(t, ref, string(content), opts)
+		if err != nil {
+			return nil, err
+		}
+		idx := current.Line - 1
+
+		lines, err = fn(lines, idx, current.Item)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.AutoID && current.Item.ID == "" {
+			assignedID = uuid.NewString()
+			lines = edit.SetProperty(lines, idx, "ID", assignedID)
+			current.Item.ID = assignedID
+		}
+		result = current
+		return []byte(strings.Join(lines, "\n")), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if assignedID != "" {
+		idx := loadIDIndex(s.IDIndexPath)
+		idx.entries[assignedID] = result.FilePath
+		_ = idx.save()
+	}
+	return result, nil
+}
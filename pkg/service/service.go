@@ -3,81 +3,218 @@ package service
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/garaemon/org-agenda-cli/pkg/agenda"
+	"github.com/garaemon/org-agenda-cli/pkg/capture"
 	"github.com/garaemon/org-agenda-cli/pkg/config"
+	"github.com/garaemon/org-agenda-cli/pkg/dateinput"
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
 	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/journal"
 	"github.com/garaemon/org-agenda-cli/pkg/parser"
 )
 
+// Service implements the operations shared by the CLI, the TUI and the MCP
+// server. AutoID assigns a UUID :ID: to a task the first time it is modified
+// and IDIndexPath is where the ID index is cached (empty disables caching).
+// TagsColumn aligns tags when a headline is edited, like org-tags-column.
+// RefileScopes selects the headlines offered by ListRefileTargets and
+// JournalPath is where changes are journaled for undo (empty disables it).
+// NoteLocation is where AddNote puts notes (NoteInLogbook or NoteInBody).
+// CaptureTemplates are the templates offered by CaptureTemplate and
+// CaptureShell enables their %(command) escapes.
 type Service struct {
-	OrgFiles    []string
-	DefaultFile string
+	OrgFiles     []string
+	DefaultFile  string
+	ParseOptions parser.Options
+	AutoID       bool
+	IDIndexPath  string
+	TagsColumn   int
+	RefileScopes []RefileScope
+	JournalPath  string
+	NoteLocation string
+
+	CaptureTemplates []capture.Template
+	CaptureShell     bool
 }
 
 func NewService(orgFiles []string, defaultFile string) *Service {
 	return &Service{
-		OrgFiles:    config.ResolveOrgFiles(orgFiles),
-		DefaultFile: defaultFile,
+		OrgFiles:     config.ResolveOrgFiles(orgFiles),
+		DefaultFile:  defaultFile,
+		ParseOptions: parser.DefaultOptions(),
+		TagsColumn:   edit.DefaultTagsColumn,
+		NoteLocation: NoteInLogbook,
 	}
 }
 
-type ListOptions struct {
-	Status string
-	Tag    string
+// NewServiceFromConfig builds a Service honoring the settings in cfg.
+func NewServiceFromConfig(cfg *config.Config) *Service {
+	s := NewService(cfg.OrgFiles, cfg.DefaultFile)
+	s.ParseOptions.Priorities = cfg.Priorities.Range()
+	s.ParseOptions.Keywords = cfg.Keywords()
+	s.ParseOptions.LogDrawer = cfg.LogDrawer()
+	s.ParseOptions.LogReschedule = config.LogSetting(cfg.LogReschedule)
+	s.ParseOptions.LogRedeadline = config.LogSetting(cfg.LogRedeadline)
+	if cfg.ArchiveLocation != "" {
+		s.ParseOptions.ArchiveLocation = cfg.ArchiveLocation
+	}
+	s.AutoID = cfg.AutoID
+	if cfg.TagsColumn != nil {
+		s.TagsColumn = *cfg.TagsColumn
+	}
+	for _, rt := range cfg.RefileTargets {
+		s.RefileScopes = append(s.RefileScopes, RefileScope{File: rt.File, MaxLevel: rt.MaxLevel})
+	}
+	s.IDIndexPath = cfg.IDIndex
+	if s.IDIndexPath == "" {
+		s.IDIndexPath = DefaultIDIndexPath()
+	}
+	if cfg.NoteLocation != "" {
+		s.NoteLocation = cfg.NoteLocation
+	}
+	s.CaptureTemplates = cfg.CaptureTemplates()
+	s.CaptureShell = cfg.Capture.ShellEscapes
+	s.JournalPath = cfg.Journal
+	if s.JournalPath == "" {
+		s.JournalPath = journal.DefaultPath()
+	}
+	return s
 }
 
-func (s *Service) ListTodos(opts ListOptions) ([]*item.Item, error) {
+// FileError is the error reading File.
+type FileError struct {
+	File string
+	Err  error
+}
+
+// ReadErrors lists the configured files that could not be read.
+type ReadErrors []FileError
+
+func (e ReadErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fmt.Sprintf("failed to read %s: %v", fe.File, fe.Err)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// LoadItems parses every configured Org file and computes which tasks are
+// blocked. The items of the readable files are returned even when others
+// cannot be read, which is reported with ReadErrors.
+func (s *Service) LoadItems() ([]*item.Item, error) {
 	var allItems []*item.Item
+	var readErrs ReadErrors
 	for _, file := range s.OrgFiles {
+		info, err := os.Stat(file)
+		if err != nil {
+			readErrs = append(readErrs, FileError{File: file, Err: err})
+			continue
+		}
 		content, err := os.ReadFile(file)
 		if err != nil {
+			readErrs = append(readErrs, FileError{File: file, Err: err})
 			continue
 		}
+		items := parser.ParseStringWithOptions(string(content), file, s.ParseOptions)
+		setFingerprints(items, string(content), info.ModTime())
+		allItems = append(allItems, items...)
+	}
+	setBlockedBy(allItems)
+	if len(readErrs) > 0 {
+		return allItems, readErrs
+	}
+	return allItems, nil
+}
 
-		items := parser.ParseString(string(content), file)
-		for _, it := range items {
-			if opts.Status != "" {
-				if it.Status != opts.Status {
-					continue
-				}
-			} else {
-				if it.Status == "" {
-					continue
-				}
+// ListOptions filters and orders the result of ListTodos. Effort is an
+// expression understood by agenda.ParseEffortFilter. A nil Sort uses
+// agenda.DefaultSortKeys. Blocked keeps only blocked tasks and Actionable
+// only tasks that are not blocked.
+type ListOptions struct {
+	Status     string
+	Tag        string
+	Effort     string
+	Sort       []string
+	Blocked    bool
+	Actionable bool
+}
+
+// ListTodos returns the tasks matching opts. Like LoadItems, it returns the
+// tasks of the readable files along with ReadErrors when some cannot be read.
+func (s *Service) ListTodos(opts ListOptions) ([]*item.Item, error) {
+	var effortMatch func(*item.Item) bool
+	if opts.Effort != "" {
+		var err error
+		if effortMatch, err = agenda.ParseEffortFilter(opts.Effort); err != nil {
+			return nil, err
+		}
+	}
+
+	items, loadErr := s.LoadItems()
+	var allItems []*item.Item
+	for _, it := range items {
+		if opts.Status != "" {
+			if it.Status != opts.Status {
+				continue
+			}
+		} else {
+			if it.Status == "" {
+				continue
 			}
+		}
 
-			if opts.Tag != "" {
-				found := false
-				for _, t := range it.Tags {
-					if t == opts.Tag {
-						found = true
-						break
-					}
-				}
-				if !found {
-					continue
+		if opts.Tag != "" {
+			found := false
+			for _, t := range it.Tags {
+				if t == opts.Tag {
+					found = true
+					break
 				}
 			}
-			allItems = append(allItems, it)
+			if !found {
+				continue
+			}
+		}
+
+		if effortMatch != nil && !effortMatch(it) {
+			continue
+		}
+		if opts.Blocked && len(it.BlockedBy) == 0 || opts.Actionable && len(it.BlockedBy) > 0 {
+			continue
 		}
+		allItems = append(allItems, it)
 	}
-	return allItems, nil
+
+	sortKeys := opts.Sort
+	if sortKeys == nil {
+		sortKeys = agenda.DefaultSortKeys
+	}
+	if err := agenda.SortItems(allItems, sortKeys); err != nil {
+		return nil, err
+	}
+	return allItems, loadErr
 }
 
+// AddOptions describes a new task. Schedule and Deadline are read with
+// dateinput.Parse and written as canonical timestamps. Review, when set,
+// receives the formatted entry and returns the text to insert instead, e.g.
+// after the user edited it.
 type AddOptions struct {
 	Priority string
 	Tags     []string
 	Schedule string
 	Deadline string
 	File     string
+	Review   func(entry string) (string, error)
 }
 
-func (s *Service) AddTodo(title string, opts AddOptions) error {
-	targetFile := opts.File
+// TargetFile returns the file new entries go to: file when given, otherwise
+// the default file or the first configured Org file.
+func (s *Service) TargetFile(file string) (string, error) {
+	targetFile := file
 	if targetFile == "" {
 		targetFile = s.DefaultFile
 	}
@@ -85,19 +222,16 @@ func (s *Service) AddTodo(title string, opts AddOptions) error {
 		targetFile = s.OrgFiles[0]
 	}
 	if targetFile == "" {
-		return fmt.Errorf("no target file specified")
-	}
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(targetFile), 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+		return "", fmt.Errorf("no target file specified")
 	}
+	return targetFile, nil
+}
 
-	f, err := os.OpenFile(targetFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+func (s *Service) AddTodo(title string, opts AddOptions) error {
+	targetFile, err := s.TargetFile(opts.File)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return err
 	}
-	defer f.Close()
 
 	content := fmt.Sprintf("* TODO %s", title)
 	if opts.Priority != "" {
@@ -109,57 +243,47 @@ func (s *Service) AddTodo(title string, opts AddOptions) error {
 	}
 	content += "\n"
 
+	var planning []string
 	if opts.Schedule != "" {
-		content += fmt.Sprintf("SCHEDULED: <%s>\n", opts.Schedule)
+		d, err := dateinput.Parse(opts.Schedule, now())
+		if err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		planning = append(planning, "SCHEDULED: "+d.Timestamp())
 	}
 	if opts.Deadline != "" {
-		content += fmt.Sprintf("DEADLINE: <%s>\n", opts.Deadline)
-	}
-
-	if _, err := f.WriteString(content); err != nil {
-		return fmt.Errorf("failed to write to file: %w", err)
-	}
-
-	return nil
-}
-
-func (s *Service) MarkDone(fileOrId string) error {
-	pos, err := parser.ParseFilePosition(fileOrId)
-	if err != nil {
-		return fmt.Errorf("failed to parse position: %w", err)
-	}
-
-	content, err := os.ReadFile(pos.FilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	lines := strings.Split(string(content), "\n")
-	if pos.Line < 1 || pos.Line > len(lines) {
-		return fmt.Errorf("line number %d out of range", pos.Line)
+		d, err := dateinput.Parse(opts.Deadline, now())
+		if err != nil {
+			return fmt.Errorf("invalid deadline: %w", err)
+		}
+		planning = append(planning, "DEADLINE: "+d.Timestamp())
 	}
-
-	lineIdx := pos.Line - 1
-	targetLine := lines[lineIdx]
-
-	if !strings.Contains(targetLine, item.StatusTodo) {
-		// Line may already be DONE or is not a task.
-		return fmt.Errorf("line does not appear to be a %s item", item.StatusTodo)
+	if len(planning) > 0 {
+		content += strings.Join(planning, " ") + "\n"
 	}
-
-	newLine := strings.Replace(targetLine, " "+item.StatusTodo+" ", " "+item.StatusDone+" ", 1)
-	if newLine == targetLine {
-		return fmt.Errorf("could not find ' %s ' pattern to replace", item.StatusTodo)
+	if opts.Review != nil {
+		if content, err = opts.Review(content); err != nil {
+			return err
+		}
+		if strings.TrimSpace(content) == "" {
+			return fmt.Errorf("the entry is empty")
+		}
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
 	}
 
-	lines[lineIdx] = newLine
-	newContent := strings.Join(lines, "\n")
+	return s.update(fmt.Sprintf("add %q", title), targetFile, func(old []byte) ([]byte, error) {
+		return appendEntry(old, content), nil
+	})
+}
 
-	if err := os.WriteFile(pos.FilePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+// appendEntry appends entry to content, making sure it starts on a new line.
+func appendEntry(content []byte, entry string) []byte {
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
 	}
-
-	return nil
+	return append(content, entry...)
 }
 
 func (s *Service) GetAgenda(startedAt time.Time, rangeType string) ([]*item.Item, error) {
@@ -177,18 +301,10 @@ func (s *Service) GetAgenda(startedAt time.Time, rangeType string) ([]*item.Item
 
 	// FilterItemsByRange handles single-day ranges correctly by truncating to the day.
 
-	var allItems []*item.Item
-	for _, file := range s.OrgFiles {
-		content, err := os.ReadFile(file)
-		if err != nil {
-			continue
-		}
-		items := parser.ParseString(string(content), file)
-		filtered := agenda.FilterItemsByRange(items, start, end)
-		allItems = append(allItems, filtered...)
+	items, _ := s.LoadItems()
+	allItems := agenda.FilterItemsByRange(items, start, end)
+	if err := agenda.SortItems(allItems, agenda.DefaultSortKeys); err != nil {
+		return nil, err
 	}
-
-	// Keep items in parsing order to maintain consistency with the original implementation.
-
 	return allItems, nil
 }
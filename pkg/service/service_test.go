@@ -1,7 +1,9 @@
 package service
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -76,6 +78,27 @@ func TestService_ListTodos(t *testing.T) {
 	}
 }
 
+func TestService_ListTodosUnreadableFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	missing := filepath.Join(dir, "missing.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	// The file disappeared after the configured files were resolved.
+	svc.OrgFiles = []string{missing, file}
+
+	items, err := svc.ListTodos(ListOptions{})
+	var readErrs ReadErrors
+	if !errors.As(err, &readErrs) || len(readErrs) != 1 || readErrs[0].File != missing || !os.IsNotExist(readErrs[0].Err) {
+		t.Fatalf("Expected a read error for %s, got %v", missing, err)
+	}
+	if len(items) != 1 || items[0].Title != "Task" {
+		t.Errorf("Expected the task of the readable file, got %v", items)
+	}
+}
+
 func TestService_AddTodo(t *testing.T) {
 	tmpfile, err := os.CreateTemp("", "test*.org")
 	if err != nil {
@@ -104,11 +127,52 @@ func TestService_AddTodo(t *testing.T) {
 	if !strings.Contains(strContent, "* TODO [#A] New Task :urgent:") {
 		t.Errorf("File missing task header, got: %s", strContent)
 	}
-	if !strings.Contains(strContent, "SCHEDULED: <2023-10-10>") {
+	if !strings.Contains(strContent, "SCHEDULED: <2023-10-10 Tue>") {
 		t.Errorf("File missing schedule, got: %s", strContent)
 	}
 }
 
+func TestService_AddTodoNoTrailingNewline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "test.org")
+	if err := os.WriteFile(file, []byte("* TODO Existing"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService([]string{file}, "")
+	if err := svc.AddTodo("New Task", AddOptions{}); err != nil {
+		t.Fatalf("AddTodo failed: %v", err)
+	}
+
+	content, _ := os.ReadFile(file)
+	if string(content) != "* TODO Existing\n* TODO New Task\n" {
+		t.Errorf("unexpected content: %q", content)
+	}
+	info, _ := os.Stat(file)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode changed to %v", info.Mode().Perm())
+	}
+}
+
+func TestService_AddTodoRelativeDates(t *testing.T) {
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	file := filepath.Join(t.TempDir(), "test.org")
+
+	svc := NewService(nil, file)
+	if err := svc.AddTodo("Task", AddOptions{Schedule: "tomorrow 3pm", Deadline: "fri"}); err != nil {
+		t.Fatalf("AddTodo failed: %v", err)
+	}
+	content, _ := os.ReadFile(file)
+	expected := "* TODO Task\nSCHEDULED: <2026-01-06 Tue 15:00> DEADLINE: <2026-01-09 Fri>\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+
+	if err := svc.AddTodo("Broken", AddOptions{Schedule: "someday"}); err == nil {
+		t.Error("Expected an error for an unreadable date")
+	}
+}
+
 func TestService_MarkDone(t *testing.T) {
 	tmpfile, err := os.CreateTemp("", "test*.org")
 	if err != nil {
@@ -126,7 +190,7 @@ func TestService_MarkDone(t *testing.T) {
 
 	// Mark done using file:line syntax (line 1)
 	filePos := tmpfile.Name() + ":1"
-	err = svc.MarkDone(filePos)
+	err = svc.MarkDone(filePos, EditOptions{})
 	if err != nil {
 		t.Fatalf("MarkDone failed: %v", err)
 	}
@@ -191,3 +255,215 @@ SCHEDULED: <2023-11-01>
 		t.Errorf("Expected 2 items for week view, got %d", len(items))
 	}
 }
+
+func TestService_ListTodos_SortedByPriority(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test*.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	content := `#+PRIORITIES: A E C
+* TODO Default
+* TODO [#E] Lowest
+* TODO [#A] Highest
+* TODO [#B] High
+  SCHEDULED: <2026-01-02 Fri>
+`
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+
+	svc := NewService([]string{tmpfile.Name()}, tmpfile.Name())
+	items, err := svc.ListTodos(ListOptions{})
+	if err != nil {
+		t.Fatalf("ListTodos failed: %v", err)
+	}
+
+	expected := []string{"Highest", "High", "Default", "Lowest"}
+	for i, title := range expected {
+		if items[i].Title != title {
+			t.Fatalf("Expected %s at %d, got %s", title, i, items[i].Title)
+		}
+	}
+
+	items, err = svc.ListTodos(ListOptions{Sort: []string{"title"}})
+	if err != nil {
+		t.Fatalf("ListTodos failed: %v", err)
+	}
+	if items[0].Title != "Default" {
+		t.Errorf("Expected Default first when sorting by title, got %s", items[0].Title)
+	}
+}
+
+func TestService_MarkDoneByID(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "tasks.org")
+	content := `* TODO Inserted later
+* TODO Task by ID
+:PROPERTIES:
+:ID: 0d3c1b9a-1111-4e2f-9c55-5b0a3f0a9d01
+:END:
+* TODO Task by custom ID
+:PROPERTIES:
+:CUSTOM_ID: weekly-report
+:END:
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService([]string{tmpDir}, "")
+	svc.IDIndexPath = filepath.Join(tmpDir, "index.json")
+
+	if err := svc.MarkDone("0d3c1b9a-1111-4e2f-9c55-5b0a3f0a9d01", EditOptions{}); err != nil {
+		t.Fatalf("MarkDone by ID failed: %v", err)
+	}
+	if err := svc.MarkDone("#weekly-report", EditOptions{}); err != nil {
+		t.Fatalf("MarkDone by CUSTOM_ID failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(file)
+	got := string(data)
+	if !strings.Contains(got, "* DONE Task by ID") || !strings.Contains(got, "* DONE Task by custom ID") {
+		t.Errorf("Tasks not marked as DONE, got: %s", got)
+	}
+	if !strings.Contains(got, "* TODO Inserted later") {
+		t.Errorf("Unrelated task modified, got: %s", got)
+	}
+
+	// The lookup populated the index.
+	index, err := os.ReadFile(svc.IDIndexPath)
+	if err != nil {
+		t.Fatalf("ID index not written: %v", err)
+	}
+	if !strings.Contains(string(index), "weekly-report") {
+		t.Errorf("ID index missing entry, got: %s", index)
+	}
+
+	if err := svc.MarkDone("id:does-not-exist", EditOptions{}); err == nil {
+		t.Error("Expected error for unknown ID")
+	}
+}
+
+func TestService_ResolveStaleIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldFile := filepath.Join(tmpDir, "old.org")
+	newFile := filepath.Join(tmpDir, "new.org")
+	if err := os.WriteFile(oldFile, []byte("* TODO Elsewhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newFile, []byte("* TODO Moved\n:PROPERTIES:\n:ID: moved-id\n:END:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	indexPath := filepath.Join(tmpDir, "index.json")
+	if err := os.WriteFile(indexPath, []byte(`{"moved-id": "`+oldFile+`"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService([]string{oldFile, newFile}, "")
+	svc.IDIndexPath = indexPath
+
+	target, err := svc.Resolve("moved-id")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if target.FilePath != newFile || target.Line != 1 {
+		t.Errorf("Unexpected target: %+v", target)
+	}
+}
+
+func TestService_MarkDoneAutoID(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	svc := NewService([]string{file}, file)
+	svc.AutoID = true
+	svc.IDIndexPath = filepath.Join(tmpDir, "index.json")
+
+	if err := svc.MarkDone(file+":1", EditOptions{}); err != nil {
+		t.Fatalf("MarkDone failed: %v", err)
+	}
+
+	items, err := svc.ListTodos(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].ID == "" {
+		t.Fatalf("Expected an ID to be assigned, got %+v", items)
+	}
+
+	data, _ := os.ReadFile(file)
+	expectedPrefix := "* DONE Task\nCLOSED: [2026-01-05 Mon 10:00] SCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: "
+	if !strings.HasPrefix(string(data), expectedPrefix) {
+		t.Errorf("Expected property drawer after planning line, got: %s", data)
+	}
+
+	// The assigned ID can address the task from now on.
+	if _, err := svc.Resolve(items[0].ID); err != nil {
+		t.Errorf("Resolve by assigned ID failed: %v", err)
+	}
+}
+
+func TestService_MarkDoneFingerprint(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "tasks.org")
+	if err := os.WriteFile(file, []byte("* TODO Write report\n* TODO Call Bob\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	svc := NewService([]string{file}, file)
+	items, err := svc.ListTodos(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := items[0]
+	if report.Fingerprint == "" {
+		t.Fatal("Expected listed items to carry a fingerprint")
+	}
+
+	// Something is captured above the task after it was listed.
+	if err := os.WriteFile(file, []byte("* TODO Inserted TODO\n* TODO Write report\n* TODO Call Bob\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := file + ":1"
+	err = svc.MarkDone(ref, EditOptions{Fingerprint: report.Fingerprint})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected a conflict error, got %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if strings.Contains(string(data), "DONE") {
+		t.Fatalf("File must not change on conflict, got: %s", data)
+	}
+
+	if err := svc.MarkDone(ref, EditOptions{Fingerprint: report.Fingerprint, Relocate: true}); err != nil {
+		t.Fatalf("MarkDone with relocation failed: %v", err)
+	}
+	data, _ = os.ReadFile(file)
+	expected := "* TODO Inserted TODO\n* DONE Write report\nCLOSED: [2026-01-05 Mon 10:00]\n* TODO Call Bob\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+
+	// The headline changed, so the old fingerprint matches nothing.
+	err = svc.MarkDone(file+":2", EditOptions{Fingerprint: report.Fingerprint, Relocate: true})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected a conflict error for a changed headline, got %v", err)
+	}
+}
+
+// setNow fixes the clock used for CLOSED and log timestamps for the duration
+// of the test.
+func setNow(t *testing.T, ts time.Time) {
+	old := now
+	now = func() time.Time { return ts }
+	t.Cleanup(func() { now = old })
+}
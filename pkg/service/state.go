@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/item"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// now is the clock behind CLOSED and state change timestamps.
+var now = time.Now
+
+// MarkDone completes the task referenced by ref, moving it to the done
+// keyword of its sequence.
+func (s *Service) MarkDone(ref string, opts EditOptions) error {
+	_, err := s.editTask("done "+ref, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		if it.Status == "" || it.Done {
+			// Line may already be DONE or is not a task.
+			return nil, fmt.Errorf("line does not appear to be a %s item", item.StatusTodo)
+		}
+		fileOpts := parser.FileOptions(strings.Join(lines, "\n"), s.ParseOptions)
+		done, ok := fileOpts.Keywords.NextDone(it.Status)
+		if !ok {
+			return nil, fmt.Errorf("no done keyword follows %s", it.Status)
+		}
+		if !opts.Force {
+			if err := s.checkBlocked(it.FilePath, lines, idx); err != nil {
+				return nil, err
+			}
+		}
+		return changeState(lines, idx, it.Status, done, "", fileOpts), nil
+	})
+	return err
+}
+
+// SetState moves the task referenced by ref to the keyword state, given by
+// name or fast-access key, and returns the name of the new keyword. CLOSED is
+// set when the task enters a done state and removed when it leaves one, and
+// the change is logged as requested by the keywords' logging markers. A
+// non-empty note is always logged.
+func (s *Service) SetState(ref, state, note string, opts EditOptions) (string, error) {
+	var name string
+	_, err := s.editTask("state "+ref+" "+state, ref, opts, func(lines []string, idx int, it *item.Item) ([]string, error) {
+		fileOpts := parser.FileOptions(strings.Join(lines, "\n"), s.ParseOptions)
+		to, ok := fileOpts.Keywords.Find(state)
+		if !ok {
+			return nil, fmt.Errorf("unknown TODO keyword %q", state)
+		}
+		if to.Name == it.Status {
+			return nil, fmt.Errorf("task is already %s", to.Name)
+		}
+		if to.Done && !it.Done && !opts.Force {
+			if err := s.checkBlocked(it.FilePath, lines, idx); err != nil {
+				return nil, err
+			}
+		}
+		name = to.Name
+		return changeState(lines, idx, it.Status, to, note, fileOpts), nil
+	})
+	return name, err
+}
+
+// changeState replaces the keyword from of the headline at idx with to and
+// records the transition the way Org's org-todo does.
+func changeState(lines []string, idx int, from string, to item.Keyword, note string, opts parser.Options) []string {
+	ts := parser.FormatTimestamp(now(), false, true)
+	lines[idx] = edit.SetKeyword(lines[idx], from, to.Name)
+
+	wasDone := opts.Keywords.IsDone(from)
+	switch {
+	case to.Done && !wasDone:
+		lines = edit.SetClosed(lines, idx, ts)
+	case !to.Done && wasDone:
+		lines = edit.RemoveClosed(lines, idx)
+	}
+
+	logging := to.Enter
+	if logging == item.LogNone && from != "" {
+		if kw, ok := opts.Keywords.Find(from); ok {
+			logging = kw.Leave
+		}
+	}
+	if logging == item.LogNone && note == "" {
+		return lines
+	}
+	heading := fmt.Sprintf("State %-12s from %-12s %s", strconv.Quote(to.Name), strconv.Quote(from), ts)
+	return edit.AddLogEntry(lines, idx, opts.LogDrawer, logEntry(heading, note))
+}
+
+// logEntry formats a log note: heading, written like org-log-note-headings,
+// as a list item with the note indented below it.
+func logEntry(heading, note string) []string {
+	heading = "- " + heading
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return []string{heading}
+	}
+	entry := []string{heading + ` \\`}
+	for _, line := range strings.Split(note, "\n") {
+		entry = append(entry, "  "+strings.TrimRight(line, " \t"))
+	}
+	return entry
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+func TestService_SetState(t *testing.T) {
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "* TODO Task\nSCHEDULED: <2026-01-05 Mon>\n:PROPERTIES:\n:ID: task-1\n:END:\nBody\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	kws, err := parser.ParseTodoKeywords("TODO WAIT(w@/!) | DONE(d!) CANCELED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.ParseOptions.Keywords = kws
+	svc.ParseOptions.LogDrawer = "LOGBOOK"
+
+	steps := []struct {
+		state, note string
+		want        string
+	}{
+		{"w", "Waiting for Bob", `* WAIT Task
+SCHEDULED: <2026-01-05 Mon>
+:PROPERTIES:
+:ID: task-1
+:END:
+:LOGBOOK:
+- State "WAIT"       from "TODO"       [2026-01-05 Mon 10:00] \\
+  Waiting for Bob
+:END:
+Body
+`},
+		{"DONE", "", `* DONE Task
+CLOSED: [2026-01-05 Mon 10:00] SCHEDULED: <2026-01-05 Mon>
+:PROPERTIES:
+:ID: task-1
+:END:
+:LOGBOOK:
+- State "DONE"       from "WAIT"       [2026-01-05 Mon 10:00]
+- State "WAIT"       from "TODO"       [2026-01-05 Mon 10:00] \\
+  Waiting for Bob
+:END:
+Body
+`},
+		{"TODO", "", `* TODO Task
+SCHEDULED: <2026-01-05 Mon>
+:PROPERTIES:
+:ID: task-1
+:END:
+:LOGBOOK:
+- State "DONE"       from "WAIT"       [2026-01-05 Mon 10:00]
+- State "WAIT"       from "TODO"       [2026-01-05 Mon 10:00] \\
+  Waiting for Bob
+:END:
+Body
+`},
+	}
+	for _, step := range steps {
+		if _, err := svc.SetState("task-1", step.state, step.note, EditOptions{}); err != nil {
+			t.Fatalf("SetState(%s) failed: %v", step.state, err)
+		}
+		data, _ := os.ReadFile(file)
+		if string(data) != step.want {
+			t.Errorf("after SetState(%s):\ngot:\n%s\nwant:\n%s", step.state, data, step.want)
+		}
+	}
+
+	if _, err := svc.SetState("task-1", "TODO", "", EditOptions{}); err == nil || !strings.Contains(err.Error(), "already") {
+		t.Errorf("Expected an error for an unchanged state, got %v", err)
+	}
+	if _, err := svc.SetState("task-1", "NEXT", "", EditOptions{}); err == nil {
+		t.Error("Expected an error for an unknown keyword")
+	}
+}
+
+func TestService_SetStateInBufferKeywords(t *testing.T) {
+	setNow(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "#+TODO: NEXT(n) | FINISHED(f!)\n* NEXT Task\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService([]string{file}, file)
+	name, err := svc.SetState(file+":2", "f", "", EditOptions{})
+	if err != nil {
+		t.Fatalf("SetState failed: %v", err)
+	}
+	if name != "FINISHED" {
+		t.Errorf("Expected FINISHED, got %s", name)
+	}
+
+	data, _ := os.ReadFile(file)
+	expected := "#+TODO: NEXT(n) | FINISHED(f!)\n* FINISHED Task\nCLOSED: [2026-01-05 Mon 10:00]\n" +
+		`- State "FINISHED"   from "NEXT"       [2026-01-05 Mon 10:00]` + "\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
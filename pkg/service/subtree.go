@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+	"github.com/garaemon/org-agenda-cli/pkg/journal"
+	"github.com/garaemon/org-agenda-cli/pkg/parser"
+)
+
+// SubtreeEdit is the text of a subtree handed out by ExtractSubtree so that
+// it can be edited as a whole and written back with ReplaceSubtree.
+type SubtreeEdit struct {
+	Target *Target
+	Text   string
+
+	ref   string
+	start int
+	count int
+	hash  string
+}
+
+// ExtractSubtree returns the subtree of the task referenced by ref: the
+// headline with its body and descendants.
+func (s *Service) ExtractSubtree(ref string, opts EditOptions) (*SubtreeEdit, error) {
+	t, err := s.resolveForEdit(ref, opts)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(t.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	current, lines, err := s.locate(t, ref, string(content), opts)
+	if err != nil {
+		return nil, err
+	}
+	start := current.Line - 1
+	end := edit.SubtreeEnd(lines, start)
+	if end == len(lines) && end > start+1 && lines[end-1] == "" {
+		// Leave the final newline of the file out of the subtree.
+		end--
+	}
+	subtree := lines[start:end]
+	return &SubtreeEdit{
+		Target: current,
+		Text:   strings.Join(subtree, "\n") + "\n",
+		ref:    ref,
+		start:  start,
+		count:  len(subtree),
+		hash:   journal.Hash(content),
+	}, nil
+}
+
+// ReplaceSubtree writes text in place of the subtree extracted as e and
+// returns the position of its first headline. It fails with ErrConflict when
+// the file changed since the subtree was extracted.
+func (s *Service) ReplaceSubtree(e *SubtreeEdit, text string) (*Target, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("the edited subtree is empty")
+	}
+	replacement := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if !edit.IsHeadline(replacement[0]) {
+		return nil, fmt.Errorf("the edited subtree must start with a headline")
+	}
+
+	file := e.Target.FilePath
+	var result *Target
+	err := s.update("edit "+e.ref, file, func(content []byte) ([]byte, error) {
+		if journal.Hash(content) != e.hash {
+			return nil, fmt.Errorf("%w: %s changed while the subtree was being edited", ErrConflict, file)
+		}
+		lines := strings.Split(string(content), "\n")
+		updated := make([]string, 0, len(lines)-e.count+len(replacement))
+		updated = append(updated, lines[:e.start]...)
+		updated = append(updated, replacement...)
+		updated = append(updated, lines[e.start+e.count:]...)
+
+		output := strings.Join(updated, "\n")
+		for _, it := range parser.ParseStringWithOptions(output, file, s.ParseOptions) {
+			if it.LineNumber == e.start+1 {
+				result = &Target{FilePath: file, Line: it.LineNumber, Item: it}
+				break
+			}
+		}
+		return []byte(output), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestService_ReplaceSubtree(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "tasks.org")
+	content := "#+TITLE: Tasks\n* TODO Project\nBody\n** TODO Step\n\n* TODO Next\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+
+	subtree, err := svc.ExtractSubtree(file+":2", EditOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subtree.Text != "* TODO Project\nBody\n** TODO Step\n\n" {
+		t.Errorf("unexpected subtree text %q", subtree.Text)
+	}
+
+	for _, text := range []string{"", "  \n", "Body only\n"} {
+		if _, err := svc.ReplaceSubtree(subtree, text); err == nil {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+
+	target, err := svc.ReplaceSubtree(subtree, "* TODO Project renamed\n** DONE Step\n** TODO Another step\n\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Line != 2 || target.Item.Title != "Project renamed" {
+		t.Errorf("unexpected target %+v", target)
+	}
+	want := "#+TITLE: Tasks\n* TODO Project renamed\n** DONE Step\n** TODO Another step\n\n* TODO Next\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// The last subtree of a file keeps the final newline.
+	last, err := svc.ExtractSubtree(file+":6", EditOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Text != "* TODO Next\n" {
+		t.Errorf("unexpected subtree text %q", last.Text)
+	}
+	if _, err := svc.ReplaceSubtree(last, "* TODO Next\nWith a body"); err != nil {
+		t.Fatal(err)
+	}
+	want = "#+TITLE: Tasks\n* TODO Project renamed\n** DONE Step\n** TODO Another step\n\n* TODO Next\nWith a body\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// A file that changed while the subtree was being edited is left alone.
+	stale, err := svc.ExtractSubtree(file+":2", EditOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.MarkDone(file+":4", EditOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(file)
+	if _, err := svc.ReplaceSubtree(stale, "* TODO Overwritten\n"); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+	if after, _ := os.ReadFile(file); string(after) != string(before) {
+		t.Errorf("file must not change on conflict, got:\n%s", after)
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/garaemon/org-agenda-cli/pkg/edit"
+)
+
+// PromoteSubtree promotes the task referenced by ref together with its
+// descendants by one level.
+func (s *Service) PromoteSubtree(ref string, opts EditOptions) (*Target, error) {
+	return s.editTree("promote "+ref, ref, opts, func(lines []string, idx int) ([]string, int, error) {
+		lines, err := edit.PromoteSubtree(lines, idx)
+		return lines, idx, err
+	})
+}
+
+// DemoteSubtree demotes the task referenced by ref together with its
+// descendants by one level.
+func (s *Service) DemoteSubtree(ref string, opts EditOptions) (*Target, error) {
+	return s.editTree("demote "+ref, ref, opts, func(lines []string, idx int) ([]string, int, error) {
+		return edit.DemoteSubtree(lines, idx), idx, nil
+	})
+}
+
+// MoveSubtreeUp swaps the subtree of the task referenced by ref with the
+// previous sibling and returns the task's new position.
+func (s *Service) MoveSubtreeUp(ref string, opts EditOptions) (*Target, error) {
+	return s.editTree("move-up "+ref, ref, opts, edit.MoveSubtreeUp)
+}
+
+// MoveSubtreeDown swaps the subtree of the task referenced by ref with the
+// next sibling and returns the task's new position.
+func (s *Service) MoveSubtreeDown(ref string, opts EditOptions) (*Target, error) {
+	return s.editTree("move-down "+ref, ref, opts, edit.MoveSubtreeDown)
+}
+
+// DeleteSubtree removes the task referenced by ref with its body and
+// descendants and returns where it was. The deletion can be undone.
+func (s *Service) DeleteSubtree(ref string, opts EditOptions) (*Target, error) {
+	return s.editTree("delete "+ref, ref, opts, func(lines []string, idx int) ([]string, int, error) {
+		return edit.DeleteSubtree(lines, idx), idx, nil
+	})
+}
+
+// editTree applies the structural edit fn to the subtree of the task
+// referenced by ref under the file lock. fn receives the index of the
+// headline and returns the new lines and the headline's new index. Unlike
+// editTask it leaves the entry itself alone, so no :ID: is assigned.
+func (s *Service) editTree(op, ref string, opts EditOptions, fn func(lines []string, idx int) ([]string, int, error)) (*Target, error) {
+	t, err := s.resolveForEdit(ref, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *Target
+	err = s.update(op, t.FilePath, func(content []byte) ([]byte, error) {
+		current, lines, err := s.locate(t, ref, string(content), opts)
+		if err != nil {
+			return nil, err
+		}
+		lines, idx, err := fn(lines, current.Line-1)
+		if err != nil {
+			return nil, err
+		}
+		result = &Target{FilePath: t.FilePath, Line: idx + 1, Item: current.Item}
+		return []byte(strings.Join(lines, "\n")), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
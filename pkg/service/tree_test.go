@@ -0,0 +1,58 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestService_TreeEdits(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.org")
+	content := `* Projects
+** TODO First
+:PROPERTIES:
+:ID: first
+:END:
+** TODO Second
+*** TODO Child
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService([]string{file}, file)
+	svc.JournalPath = filepath.Join(dir, "journal.jsonl")
+
+	moved, err := svc.MoveSubtreeDown("first", EditOptions{})
+	if err != nil {
+		t.Fatalf("MoveSubtreeDown failed: %v", err)
+	}
+	if moved.Line != 4 {
+		t.Errorf("Expected the task at line 4, got %d", moved.Line)
+	}
+	if _, err := svc.PromoteSubtree(file+":2", EditOptions{}); err != nil {
+		t.Fatalf("PromoteSubtree failed: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	expected := "* Projects\n* TODO Second\n** TODO Child\n** TODO First\n:PROPERTIES:\n:ID: first\n:END:\n"
+	if string(data) != expected {
+		t.Errorf("Unexpected content:\ngot  %q\nwant %q", data, expected)
+	}
+
+	if _, err := svc.DeleteSubtree(file+":2", EditOptions{}); err != nil {
+		t.Fatalf("DeleteSubtree failed: %v", err)
+	}
+	data, _ = os.ReadFile(file)
+	if string(data) != "* Projects\n" {
+		t.Errorf("Unexpected content after delete: %q", data)
+	}
+
+	if _, err := svc.Undo(1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	data, _ = os.ReadFile(file)
+	if string(data) != expected {
+		t.Errorf("Undo did not restore the deleted subtree: %q", data)
+	}
+}
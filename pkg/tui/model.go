@@ -39,6 +39,9 @@ func (i ListItem) Description() string {
 	if i.Item.Deadline != nil {
 		parts = append(parts, fmt.Sprintf("Ddl: %s", i.Item.Deadline.Format("2006-01-02")))
 	}
+	if i.Item.Effort != "" {
+		parts = append(parts, fmt.Sprintf("Eff: %s", i.Item.Effort))
+	}
 	if len(i.Item.Tags) > 0 {
 		parts = append(parts, fmt.Sprintf(":%s:", strings.Join(i.Item.Tags, ":")))
 	}
@@ -183,3 +183,10 @@ func TestPagingMonth(t *testing.T) {
 		t.Errorf("Expected Item 1, got %s", m.list.Items()[0].(ListItem).Item.Title)
 	}
 }
+
+func TestListItemDescriptionEffort(t *testing.T) {
+	li := ListItem{Item: &item.Item{Title: "Estimated", Status: "TODO", Effort: "1:30"}}
+	if desc := li.Description(); !strings.Contains(desc, "Eff: 1:30") {
+		t.Errorf("description should contain the effort, got '%s'", desc)
+	}
+}